@@ -0,0 +1,287 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Finding is an error or warning found in a log or reported by Azure
+// DevOps. Identical findings are merged; Count says how often they occurred
+// and LogId and Line point to the first occurrence.
+type Finding struct {
+	Severity string `json:"severity"` // error or warning
+	Source   string `json:"source"`   // pipeline, msbuild, dotnet test, npm, maven, go test, pytest, adomcp
+	Message  string `json:"message"`
+	Task     string `json:"task,omitempty"`
+	LogId    int    `json:"logId,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Count    int    `json:"count"`
+}
+
+// FailedTask is a task, job or environment that failed or was canceled.
+type FailedTask struct {
+	Name   string `json:"name"`
+	Result string `json:"result"`
+	LogId  int    `json:"logId,omitempty"`
+}
+
+// FailureSummary is the outcome of a build or release together with the
+// tasks that failed and the errors and warnings found for them.
+type FailureSummary struct {
+	Title       string       `json:"title"`
+	Status      string       `json:"status"`
+	Result      string       `json:"result,omitempty"`
+	FailedTasks []FailedTask `json:"failedTasks"`
+	Findings    []Finding    `json:"findings"`
+}
+
+// maxFindingMessage caps the length of a finding message, as some tools
+// print whole stack traces or command lines on a single line.
+const maxFindingMessage = 500
+
+var logTimestamp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z ?`)
+
+// logPatterns recognize errors and warnings in log lines, after the
+// timestamp is removed. The first match wins. The message is the first
+// capture group, or the whole line if the pattern has none.
+var logPatterns = []struct {
+	severity string
+	source   string
+	re       *regexp.Regexp
+}{
+	{"error", "pipeline", regexp.MustCompile(`^##\[error\](.+)`)},
+	{"warning", "pipeline", regexp.MustCompile(`^##\[warning\](.+)`)},
+	{"error", "msbuild", regexp.MustCompile(`: (?:fatal )?error [A-Z]+\d+: `)},
+	{"warning", "msbuild", regexp.MustCompile(`: warning [A-Z]+\d+: `)},
+	{"error", "dotnet test", regexp.MustCompile(`^\s*(Failed \S+) \[`)},
+	{"error", "npm", regexp.MustCompile(`^npm (?:ERR!|error) (\S.*)`)},
+	{"error", "maven", regexp.MustCompile(`^\[ERROR\] (\S.*)`)},
+	{"error", "go test", regexp.MustCompile(`^\s*(--- FAIL: .+)`)},
+	{"error", "go test", regexp.MustCompile(`^(panic: .+)`)},
+	{"error", "pytest", regexp.MustCompile(`^((?:FAILED|ERROR) \S.*)`)},
+}
+
+// AnalyzeLog scans a log for errors and warnings. The findings carry their
+// line number but no log or task, and are not merged.
+func AnalyzeLog(content string) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(content, "\n") {
+		if f, ok := matchLogLine(line); ok {
+			f.Line = i + 1
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// LineSeverity reports whether a log line is an error or a warning, as
+// recognized by AnalyzeLog. It returns "" for any other line.
+func LineSeverity(line string) string {
+	f, _ := matchLogLine(line)
+	return f.Severity
+}
+
+func matchLogLine(line string) (Finding, bool) {
+	line = logTimestamp.ReplaceAllString(strings.TrimRight(line, "\r"), "")
+	for _, p := range logPatterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		message := line
+		if len(m) > 1 {
+			message = m[1]
+		}
+		return Finding{Severity: p.severity, Source: p.source, Message: message}, true
+	}
+	return Finding{}, false
+}
+
+// findingSet merges findings with the same severity and message.
+type findingSet struct {
+	index    map[string]int
+	findings []Finding
+}
+
+func (s *findingSet) add(f Finding) {
+	f.Message = strings.TrimSpace(f.Message)
+	if len(f.Message) > maxFindingMessage {
+		f.Message = f.Message[:maxFindingMessage] + "..."
+	}
+	if f.Message == "" {
+		return
+	}
+
+	key := f.Severity + "\x00" + f.Message
+	if i, ok := s.index[key]; ok {
+		existing := &s.findings[i]
+		// Issues reported by Azure DevOps often lack a line number that the
+		// matching ##[error] line in the log provides. The line is the same
+		// occurrence as the issue, so it is not counted again.
+		if existing.Line == 0 && f.Line > 0 && (existing.LogId == 0 || existing.LogId == f.LogId) {
+			existing.LogId, existing.Line = f.LogId, f.Line
+			return
+		}
+		if existing.Line > 0 && existing.LogId == f.LogId && existing.Line == f.Line {
+			return
+		}
+		existing.Count++
+		return
+	}
+
+	if s.index == nil {
+		s.index = map[string]int{}
+	}
+	f.Count = 1
+	s.index[key] = len(s.findings)
+	s.findings = append(s.findings, f)
+}
+
+// addLog adds the findings of a fetched log, or a warning if the log could
+// not be fetched, so that the summary does not silently miss it.
+func (s *findingSet) addLog(result logResult, task string, logId int) {
+	if result.err != nil {
+		s.add(Finding{
+			Severity: "warning",
+			Source:   "adomcp",
+			Message:  fmt.Sprintf("log could not be fetched: %v", result.err),
+			Task:     task,
+			LogId:    logId,
+		})
+		return
+	}
+	for _, f := range AnalyzeLog(result.content) {
+		f.Task, f.LogId = task, logId
+		s.add(f)
+	}
+}
+
+// sorted returns the errors before the warnings, each in order of first
+// occurrence.
+func (s *findingSet) sorted() []Finding {
+	var errors, warnings []Finding
+	for _, f := range s.findings {
+		if f.Severity == "error" {
+			errors = append(errors, f)
+		} else {
+			warnings = append(warnings, f)
+		}
+	}
+	return append(errors, warnings...)
+}
+
+// AnalyzeBuild summarizes why a build failed from its timeline issues and
+// the logs of its failed tasks (see GetFailedBuildLogs).
+func (c *Client) AnalyzeBuild(ctx context.Context, project string, buildId int) (*FailureSummary, error) {
+	build, err := c.GetBuild(ctx, project, buildId)
+	if err != nil {
+		return nil, err
+	}
+	timeline, err := c.GetBuildTimeline(ctx, project, buildId)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &FailureSummary{
+		Title:  fmt.Sprintf("Build %s of pipeline %q", build.BuildNumber, build.Definition.Name),
+		Status: build.Status,
+		Result: build.Result,
+	}
+
+	var findings findingSet
+	for _, r := range timeline.Records {
+		for _, issue := range r.Issues {
+			line, _ := strconv.Atoi(issue.Data["logFileLineNumber"])
+			findings.add(Finding{
+				Severity: issue.Type,
+				Source:   "pipeline",
+				Message:  c.Redactor.Redact(issue.Message),
+				Task:     r.Name,
+				LogId:    r.LogId(),
+				Line:     line,
+			})
+		}
+	}
+
+	failed := timeline.failedRecords()
+	results, err := c.fetchLogs(ctx, len(failed), nil, func(ctx context.Context, i int) (string, error) {
+		return c.getBuildLogLines(ctx, project, buildId, failed[i].LogId(), 0, 0, build.Status == "completed")
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range failed {
+		if r.Result == "failed" || r.Result == "canceled" {
+			summary.FailedTasks = append(summary.FailedTasks, FailedTask{Name: r.Name, Result: r.Result, LogId: r.LogId()})
+		}
+		findings.addLog(results[i], r.Name, r.LogId())
+	}
+
+	summary.Findings = findings.sorted()
+	return summary, nil
+}
+
+// AnalyzeRelease summarizes why a release failed from the issues and logs
+// of its failed tasks.
+func (c *Client) AnalyzeRelease(ctx context.Context, project string, releaseId int) (*FailureSummary, error) {
+	release, err := c.GetRelease(ctx, project, releaseId)
+	if err != nil {
+		return nil, err
+	}
+	detail, err := c.getReleaseDetail(ctx, project, releaseId)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &FailureSummary{
+		Title:  fmt.Sprintf("Release %s of %q", release.Name, release.ReleaseDefinition.Name),
+		Status: release.Status,
+	}
+
+	type failedTask struct {
+		name   string
+		logUrl string
+	}
+	var failed []failedTask
+	var findings findingSet
+	for i, env := range detail.Environments {
+		if env.Status == "rejected" || env.Status == "canceled" || env.Status == "partiallySucceeded" {
+			summary.FailedTasks = append(summary.FailedTasks, FailedTask{Name: env.Name, Result: env.Status})
+		}
+		for _, task := range detail.tasks(i) {
+			name := env.Name + " / " + task.Name
+			for _, issue := range task.Issues {
+				line, _ := strconv.Atoi(issue.Data["logFileLineNumber"])
+				findings.add(Finding{
+					Severity: strings.ToLower(issue.IssueType),
+					Source:   "pipeline",
+					Message:  c.Redactor.Redact(issue.Message),
+					Task:     name,
+					Line:     line,
+				})
+			}
+			if task.Status == "failed" || task.Status == "canceled" {
+				summary.FailedTasks = append(summary.FailedTasks, FailedTask{Name: name, Result: task.Status})
+				if task.LogUrl != "" {
+					failed = append(failed, failedTask{name: name, logUrl: task.LogUrl})
+				}
+			}
+		}
+	}
+
+	results, err := c.fetchLogs(ctx, len(failed), nil, func(ctx context.Context, i int) (string, error) {
+		return c.getReleaseTaskLog(ctx, failed[i].logUrl, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, task := range failed {
+		findings.addLog(results[i], task.name, 0)
+	}
+
+	summary.Findings = findings.sorted()
+	return summary, nil
+}
@@ -0,0 +1,320 @@
+package azuredevops
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache stores responses of the Azure DevOps API by request. Entries are
+// shared between callers and must not be modified once stored.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Put(key string, entry *CacheEntry)
+}
+
+// CacheEntry is a cached response body together with what is needed to
+// decide whether it can still be used.
+type CacheEntry struct {
+	Body              []byte
+	ContinuationToken string
+	ETag              string    // sent as If-None-Match to revalidate the entry
+	Expires           time.Time // the entry is used without revalidation until then
+	Final             bool      // the body is a finished log and never changes
+}
+
+// fresh reports whether the entry can be used without asking the server.
+func (e *CacheEntry) fresh() bool {
+	return e.Final || time.Now().Before(e.Expires)
+}
+
+func (e *CacheEntry) size() int64 {
+	return int64(len(e.Body) + len(e.ContinuationToken) + len(e.ETag))
+}
+
+// DefaultCacheSize is the size, in bytes, of the memory cache set up by
+// NewClient.
+const DefaultCacheSize = 256 << 20
+
+// DefaultListTTL is the Client.ListTTL set by NewClient.
+const DefaultListTTL = 30 * time.Second
+
+// MemoryCache is a Cache that keeps up to a number of bytes of entries in
+// memory, evicting the least recently used ones first.
+type MemoryCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List // of *memoryCacheItem, most recently used first
+	entries map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache returns an empty MemoryCache holding up to maxBytes of
+// response data. Larger responses are not cached.
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).entry, true
+}
+
+func (c *MemoryCache) Put(key string, entry *CacheEntry) {
+	size := int64(len(key)) + entry.size()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	if size > c.maxBytes {
+		return
+	}
+	c.entries[key] = c.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	c.size += size
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *MemoryCache) remove(elem *list.Element) {
+	item := c.order.Remove(elem).(*memoryCacheItem)
+	delete(c.entries, item.key)
+	c.size -= int64(len(item.key)) + item.entry.size()
+}
+
+// DiskCache is a Cache that keeps finished logs as files in a directory, so
+// that they survive restarts. Other entries would soon be
+// stale and are not stored. Files are never removed by the cache.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing its files in dir, which is
+// created if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry CacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *DiskCache) Put(key string, entry *CacheEntry) {
+	if !entry.Final {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry); err != nil {
+		log.Printf("Failed to cache response: %v", err)
+		return
+	}
+	// Write to a temporary file first so that readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		log.Printf("Failed to cache response: %v", err)
+		return
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("Failed to cache response: %v", err)
+	}
+}
+
+// tieredCache looks entries up in each cache in turn, copying entries
+// found in a later cache into the earlier ones.
+type tieredCache []Cache
+
+// NewTieredCache returns a Cache that combines caches, fastest first, such
+// as a MemoryCache in front of a DiskCache.
+func NewTieredCache(caches ...Cache) Cache {
+	return tieredCache(caches)
+}
+
+func (t tieredCache) Get(key string) (*CacheEntry, bool) {
+	for i, cache := range t {
+		if entry, ok := cache.Get(key); ok {
+			for _, faster := range t[:i] {
+				faster.Put(key, entry)
+			}
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+func (t tieredCache) Put(key string, entry *CacheEntry) {
+	for _, cache := range t {
+		cache.Put(key, entry)
+	}
+}
+
+// cachePolicy says how the response to a request may be cached.
+type cachePolicy struct {
+	// list responses are used without revalidation for Client.ListTTL
+	list bool
+	// final responses are logs that no longer change, such as those of a
+	// completed build, and are cached for good. Other responses are
+	// revalidated with their ETag on every use. Builds and releases are
+	// never final, as failed jobs can be rerun and environments redeployed.
+	final bool
+	// log bodies are redacted before they are cached and returned
+	log bool
+}
+
+// finishedStatus reports whether a release task status is final.
+func finishedStatus(status string) bool {
+	switch status {
+	case "succeeded", "partiallySucceeded", "failed", "rejected", "canceled", "skipped":
+		return true
+	default:
+		return false
+	}
+}
+
+// buildCompleted reports whether a build has completed, in which case the
+// logs it has no longer change. It is only asked when responses are cached.
+func (c *Client) buildCompleted(ctx context.Context, project string, buildId int) bool {
+	if c.Cache == nil {
+		return false
+	}
+	build, err := c.GetBuild(ctx, project, buildId)
+	return err == nil && build.Status == "completed"
+}
+
+// cacheKey identifies the response to req. Keys include a hash of the
+// token, so that a cache shared by several tokens never serves one the
+// data of another.
+func (c *Client) cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(c.Token))
+	return hex.EncodeToString(sum[:8]) + " " + req.URL.String()
+}
+
+// get performs the GET request req and returns its response, from c.Cache
+// when policy allows it. Concurrent identical requests share one call to
+// the server.
+func (c *Client) get(req *http.Request, policy cachePolicy) (*CacheEntry, error) {
+	key := c.cacheKey(req)
+	for {
+		entry, shared, err := c.flights.do(req.Context(), key, func() (*CacheEntry, error) {
+			return c.load(req, key, policy)
+		})
+		// A shared call fails with the context error of the caller that made
+		// it if that caller gives up; the others try again themselves
+		if shared && err != nil && req.Context().Err() == nil &&
+			(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			continue
+		}
+		return entry, err
+	}
+}
+
+// load is get without the sharing of calls.
+func (c *Client) load(req *http.Request, key string, policy cachePolicy) (*CacheEntry, error) {
+	var cached *CacheEntry
+	if c.Cache != nil {
+		if entry, ok := c.Cache.Get(key); ok {
+			if entry.fresh() {
+				return entry, nil
+			}
+			if entry.ETag != "" {
+				cached = entry
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+		}
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		if policy.list && c.ListTTL > 0 {
+			renewed := *cached
+			renewed.Expires = time.Now().Add(c.ListTTL)
+			c.Cache.Put(key, &renewed)
+		}
+		return cached, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if policy.log {
+		body = []byte(c.Redactor.Redact(string(body)))
+	}
+	entry := &CacheEntry{
+		Body:              body,
+		ContinuationToken: resp.Header.Get("x-ms-continuationtoken"),
+		ETag:              resp.Header.Get("ETag"),
+	}
+	if c.Cache == nil {
+		return entry, nil
+	}
+
+	switch {
+	case policy.final:
+		entry.Final = true
+	case policy.list && c.ListTTL > 0:
+		entry.Expires = time.Now().Add(c.ListTTL)
+	case entry.ETag == "":
+		// Nothing to revalidate the entry with
+		return entry, nil
+	}
+	c.Cache.Put(key, entry)
+	return entry, nil
+}
@@ -1,12 +1,18 @@
 package azuredevops
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Client struct {
@@ -15,6 +21,32 @@ type Client struct {
 	Project      string
 	Token        string
 	HTTPClient   *http.Client
+
+	// Redactor removes secrets from every log the client returns. NewClient
+	// sets it up to redact the built-in detectors and Token.
+	Redactor *Redactor
+
+	// Concurrency is the maximum number of logs fetched in parallel by
+	// calls that fetch several logs (default 4).
+	Concurrency int
+
+	// Retry is the policy for transient failures. NewClient sets it to
+	// DefaultRetryPolicy.
+	Retry RetryPolicy
+
+	// Cache stores responses: finished logs for good, lists for ListTTL, and anything with an ETag until the server reports
+	// a change. NewClient sets it to a MemoryCache of DefaultCacheSize; nil
+	// disables caching.
+	Cache Cache
+
+	// ListTTL is how long list responses are cached (DefaultListTTL when
+	// set by NewClient). Zero disables caching of lists.
+	ListTTL time.Duration
+
+	throttleMu sync.Mutex
+	notBefore  time.Time // no request is sent before this time
+
+	flights flightGroup // GET requests in flight
 }
 
 func NewClient(baseURL, organization, project, token string) *Client {
@@ -24,10 +56,14 @@ func NewClient(baseURL, organization, project, token string) *Client {
 		Project:      project,
 		Token:        token,
 		HTTPClient:   &http.Client{},
+		Redactor:     NewRedactor(token),
+		Retry:        DefaultRetryPolicy,
+		Cache:        NewMemoryCache(DefaultCacheSize),
+		ListTTL:      DefaultListTTL,
 	}
 }
 
-func (c *Client) getRequest(project, path string) (*http.Request, error) {
+func (c *Client) getRequest(ctx context.Context, project, path string) (*http.Request, error) {
 	// Construct URL for on-premise: https://{server}/{organization}/{project}/_apis/{area}/{resource}?api-version={version}
 	
 	targetProject := c.Project
@@ -42,7 +78,7 @@ func (c *Client) getRequest(project, path string) (*http.Request, error) {
 		fullURL = fmt.Sprintf("%s/_apis/%s", c.BaseURL, path)
 	}
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -54,24 +90,87 @@ func (c *Client) getRequest(project, path string) (*http.Request, error) {
 	return req, nil
 }
 
-func (c *Client) doRequest(req *http.Request, v interface{}) error {
-	resp, err := c.HTTPClient.Do(req)
+func (c *Client) doRequest(req *http.Request, v interface{}, policy cachePolicy) error {
+	_, err := c.doRequestPage(req, v, policy)
+	return err
+}
+
+// doRequestPage is like doRequest but also returns the continuation token
+// that list endpoints send when more results are available.
+func (c *Client) doRequestPage(req *http.Request, v interface{}, policy cachePolicy) (string, error) {
+	entry, err := c.get(req, policy)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if v != nil {
+		if err := json.Unmarshal(entry.Body, v); err != nil {
+			return "", err
+		}
 	}
-	defer resp.Body.Close()
+	return entry.ContinuationToken, nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+// doRequestRaw is like doRequest but returns the body as-is, for plain text
+// endpoints such as logs. The body is redacted.
+func (c *Client) doRequestRaw(req *http.Request, policy cachePolicy) ([]byte, error) {
+	policy.log = true
+	entry, err := c.get(req, policy)
+	if err != nil {
+		return nil, err
 	}
+	return entry.Body, nil
+}
 
-	if v != nil {
-		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
-			return err
+// send performs req and turns non-2xx responses, other than 304 Not
+// Modified, into *APIError. Transient
+// failures are retried according to c.Retry, and rate limits announced by
+// the server delay all further requests of the client. The caller must
+// close the body of the returned response.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		if err := c.waitThrottle(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		var delay time.Duration
+		if err == nil {
+			delay = serverDelay(resp)
+			c.throttle(delay)
+			// With an invalid token, some servers answer 203 with a sign-in page
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 && resp.StatusCode != http.StatusNonAuthoritativeInfo ||
+				resp.StatusCode == http.StatusNotModified {
+				return resp, nil
+			}
+
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			err = newAPIError(resp, bodyBytes)
+			if !isRetryableStatus(resp.StatusCode) {
+				return nil, err
+			}
+		} else if ctx.Err() != nil {
+			return nil, err
+		}
+
+		if attempt >= c.Retry.MaxAttempts {
+			return nil, err
+		}
+		if delay == 0 {
+			delay = c.Retry.backoff(attempt)
+		}
+		if c.Retry.MaxElapsed > 0 && time.Since(start)+delay > c.Retry.MaxElapsed {
+			return nil, err
+		}
+
+		log.Printf("Retrying %s in %v (attempt %d of %d): %v", req.URL.Path, delay.Round(time.Millisecond), attempt+1, c.Retry.MaxAttempts, err)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
 		}
 	}
-	return nil
 }
 
 // Build definitions
@@ -91,80 +190,247 @@ type Build struct {
 	Definition  struct {
 		Name string `json:"name"`
 	} `json:"definition"`
+	Project struct {
+		Name string `json:"name"`
+	} `json:"project"`
+}
+
+// BuildPage is one page of a build list. ContinuationToken is empty on the
+// last page.
+type BuildPage struct {
+	Builds            []Build
+	ContinuationToken string
+}
+
+// BuildQuery filters a build list. Zero-valued fields are not sent.
+type BuildQuery struct {
+	Top               int
+	ContinuationToken string
+	Definitions       []int
+	BranchName        string // full ref, e.g. refs/heads/main
+	ResultFilter      string // succeeded, partiallySucceeded, failed, canceled
+	StatusFilter      string // inProgress, completed, cancelling, postponed, notStarted, all
+	ReasonFilter      string // manual, individualCI, batchedCI, schedule, pullRequest, ...
+	RequestedFor      string
+	TagFilters        []string
+	MinTime           time.Time
+	MaxTime           time.Time
+	QueryOrder        string // e.g. finishTimeDescending, queueTimeAscending
+	RepositoryId      string
+	RepositoryType    string // defaults to TfsGit when RepositoryId is set
+}
+
+func (q BuildQuery) values() url.Values {
+	v := url.Values{}
+	v.Set("api-version", "6.0")
+	if q.Top > 0 {
+		v.Set("$top", strconv.Itoa(q.Top))
+	}
+	if q.ContinuationToken != "" {
+		v.Set("continuationToken", q.ContinuationToken)
+	}
+	if len(q.Definitions) > 0 {
+		ids := make([]string, len(q.Definitions))
+		for i, id := range q.Definitions {
+			ids[i] = strconv.Itoa(id)
+		}
+		v.Set("definitions", strings.Join(ids, ","))
+	}
+	if q.BranchName != "" {
+		v.Set("branchName", q.BranchName)
+	}
+	if q.ResultFilter != "" {
+		v.Set("resultFilter", q.ResultFilter)
+	}
+	if q.StatusFilter != "" {
+		v.Set("statusFilter", q.StatusFilter)
+	}
+	if q.ReasonFilter != "" {
+		v.Set("reasonFilter", q.ReasonFilter)
+	}
+	if q.RequestedFor != "" {
+		v.Set("requestedFor", q.RequestedFor)
+	}
+	if len(q.TagFilters) > 0 {
+		v.Set("tagFilters", strings.Join(q.TagFilters, ","))
+	}
+	if !q.MinTime.IsZero() {
+		v.Set("minTime", q.MinTime.UTC().Format(time.RFC3339))
+	}
+	if !q.MaxTime.IsZero() {
+		v.Set("maxTime", q.MaxTime.UTC().Format(time.RFC3339))
+	}
+	if q.QueryOrder != "" {
+		v.Set("queryOrder", q.QueryOrder)
+	}
+	if q.RepositoryId != "" {
+		v.Set("repositoryId", q.RepositoryId)
+		repoType := q.RepositoryType
+		if repoType == "" {
+			repoType = "TfsGit"
+		}
+		v.Set("repositoryType", repoType)
+	}
+	return v
 }
 
-func (c *Client) GetBuilds(project string, top int) ([]Build, error) {
-	path := fmt.Sprintf("build/builds?api-version=6.0&$top=%d", top)
-	req, err := c.getRequest(project, path)
+// GetBuilds lists the builds matching q.
+func (c *Client) GetBuilds(ctx context.Context, project string, q BuildQuery) (*BuildPage, error) {
+	path := "build/builds?" + q.values().Encode()
+	req, err := c.getRequest(ctx, project, path)
 	if err != nil {
 		return nil, err
 	}
 
 	var response BuildListResponse
-	if err := c.doRequest(req, &response); err != nil {
+	token, err := c.doRequestPage(req, &response, cachePolicy{list: true})
+	if err != nil {
 		return nil, err
 	}
-	return response.Value, nil
+	return &BuildPage{Builds: response.Value, ContinuationToken: token}, nil
 }
 
-func (c *Client) GetBuild(project string, buildId int) (*Build, error) {
+func (c *Client) GetBuild(ctx context.Context, project string, buildId int) (*Build, error) {
 	path := fmt.Sprintf("build/builds/%d?api-version=6.0", buildId)
-	req, err := c.getRequest(project, path)
+	req, err := c.getRequest(ctx, project, path)
 	if err != nil {
 		return nil, err
 	}
 
 	var build Build
-	if err := c.doRequest(req, &build); err != nil {
+	if err := c.doRequest(req, &build, cachePolicy{}); err != nil {
 		return nil, err
 	}
 	return &build, nil
 }
 
-func (c *Client) GetBuildLogs(project string, buildId int) (string, error) {
-	// First get the logs metadata to find the log IDs
+// BuildLog is the metadata of a build log.
+type BuildLog struct {
+	Id        int    `json:"id"`
+	LineCount int    `json:"lineCount"`
+	Type      string `json:"type"`
+	Url       string `json:"url"`
+}
+
+// GetBuildLogList lists the logs of a build without their content.
+func (c *Client) GetBuildLogList(ctx context.Context, project string, buildId int) ([]BuildLog, error) {
 	path := fmt.Sprintf("build/builds/%d/logs?api-version=6.0", buildId)
-	req, err := c.getRequest(project, path)
+	req, err := c.getRequest(ctx, project, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Value []BuildLog `json:"value"`
+	}
+	if err := c.doRequest(req, &response, cachePolicy{}); err != nil {
+		return nil, err
+	}
+	return response.Value, nil
+}
+
+func (c *Client) GetBuildLogs(ctx context.Context, project string, buildId int) (string, error) {
+	// First get the logs metadata to find the log IDs
+	logList, err := c.GetBuildLogList(ctx, project, buildId)
 	if err != nil {
 		return "", err
 	}
 
-	type LogResponse struct {
-		Value []struct {
-			Id  int    `json:"id"`
-			Url string `json:"url"`
-		} `json:"value"`
+	sections := make([]logSection, len(logList))
+	for i, logItem := range logList {
+		sections[i] = logSection{id: logItem.Id, header: fmt.Sprintf("--- Log ID %d ---", logItem.Id)}
 	}
-	
-	var logResp LogResponse
-	if err := c.doRequest(req, &logResp); err != nil {
+	return c.fetchBuildLogs(ctx, project, buildId, sections)
+}
+
+// GetFailedBuildLogs fetches only the logs of the timeline records that
+// failed or were canceled, each preceded by the log of its job for setup
+// context. It returns an empty string if nothing failed.
+func (c *Client) GetFailedBuildLogs(ctx context.Context, project string, buildId int) (string, error) {
+	timeline, err := c.GetBuildTimeline(ctx, project, buildId)
+	if err != nil {
 		return "", err
 	}
 
-	var fullLogs strings.Builder
-	for _, logItem := range logResp.Value {
-		// Fetch actual log content
-		logPath := fmt.Sprintf("build/builds/%d/logs/%d?api-version=6.0", buildId, logItem.Id)
-		logReq, err := c.getRequest(project, logPath)
-		if err != nil {
-			continue
-		}
-		
-		resp, err := c.HTTPClient.Do(logReq)
-		if err != nil {
-			continue
-		}
-		defer resp.Body.Close()
-		
-		content, _ := io.ReadAll(resp.Body)
-		fullLogs.WriteString(fmt.Sprintf("--- Log ID %d ---\n", logItem.Id))
-		fullLogs.Write(content)
-		fullLogs.WriteString("\n")
+	var sections []logSection
+	for _, r := range timeline.failedRecords() {
+		sections = append(sections, logSection{
+			id:     r.LogId(),
+			header: fmt.Sprintf("--- Log ID %d: %s %q (%s) ---", r.LogId(), r.Type, r.Name, r.Result),
+		})
+	}
+	return c.fetchBuildLogs(ctx, project, buildId, sections)
+}
+
+// logSection is a build log to fetch and the header line it is written under.
+type logSection struct {
+	id     int
+	header string
+}
+
+// fetchBuildLogs fetches the given logs in parallel and concatenates them
+// in order. A log that cannot be fetched is replaced by the error.
+func (c *Client) fetchBuildLogs(ctx context.Context, project string, buildId int, sections []logSection) (string, error) {
+	completed := c.buildCompleted(ctx, project, buildId)
+	results, err := c.fetchLogs(ctx, len(sections), nil, func(ctx context.Context, i int) (string, error) {
+		return c.getBuildLogLines(ctx, project, buildId, sections[i].id, 0, 0, completed)
+	})
+	if err != nil {
+		return "", err
 	}
 
+	var fullLogs strings.Builder
+	for i, section := range sections {
+		fullLogs.WriteString(section.header + "\n")
+		writeLogResult(&fullLogs, results[i])
+	}
 	return fullLogs.String(), nil
 }
 
+func writeLogResult(w *strings.Builder, result logResult) {
+	if result.err != nil {
+		w.WriteString(fmt.Sprintf("[failed to fetch log: %v]\n", result.err))
+		return
+	}
+	w.WriteString(result.content)
+	w.WriteString("\n")
+}
+
+// GetBuildLog fetches the content of a single build log.
+func (c *Client) GetBuildLog(ctx context.Context, project string, buildId, logId int) (string, error) {
+	return c.GetBuildLogLines(ctx, project, buildId, logId, 0, 0)
+}
+
+// GetBuildLogLines fetches lines startLine through endLine of a build log.
+// Lines are numbered from 1; a zero startLine or endLine leaves that end of
+// the range open.
+func (c *Client) GetBuildLogLines(ctx context.Context, project string, buildId, logId, startLine, endLine int) (string, error) {
+	completed := c.buildCompleted(ctx, project, buildId)
+	return c.getBuildLogLines(ctx, project, buildId, logId, startLine, endLine, completed)
+}
+
+// getBuildLogLines is GetBuildLogLines for a build known to be completed
+// or not.
+func (c *Client) getBuildLogLines(ctx context.Context, project string, buildId, logId, startLine, endLine int, completed bool) (string, error) {
+	path := fmt.Sprintf("build/builds/%d/logs/%d?api-version=6.0", buildId, logId)
+	if startLine > 0 {
+		path += fmt.Sprintf("&startLine=%d", startLine)
+	}
+	if endLine > 0 {
+		path += fmt.Sprintf("&endLine=%d", endLine)
+	}
+	req, err := c.getRequest(ctx, project, path)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := c.doRequestRaw(req, cachePolicy{final: completed})
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
 // Release definitions
 type ReleaseListResponse struct {
 	Count int       `json:"count"`
@@ -180,114 +446,279 @@ type Release struct {
 	ReleaseDefinition struct {
 		Name string `json:"name"`
 	} `json:"releaseDefinition"`
+	ProjectReference struct {
+		Name string `json:"name"`
+	} `json:"projectReference"`
+	Environments []ReleaseEnvironment `json:"environments,omitempty"`
+}
+
+type ReleaseEnvironment struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// ReleasePage is one page of a release list. ContinuationToken is empty on
+// the last page.
+type ReleasePage struct {
+	Releases          []Release
+	ContinuationToken string
+}
+
+// environmentStatusFlags maps environment status names to the bit flags
+// the release API expects in environmentStatusFilter.
+var environmentStatusFlags = map[string]int{
+	"notStarted":         1,
+	"inProgress":         2,
+	"succeeded":          4,
+	"canceled":           8,
+	"rejected":           16,
+	"queued":             32,
+	"scheduled":          64,
+	"partiallySucceeded": 128,
+}
+
+// ReleaseQuery filters a release list. Zero-valued fields are not sent.
+type ReleaseQuery struct {
+	Top                     int
+	ContinuationToken       string
+	DefinitionId            int
+	DefinitionEnvironmentId int
+	StatusFilter            string   // draft, active, abandoned
+	EnvironmentStatusFilter []string // any of notStarted, inProgress, succeeded, canceled, rejected, queued, scheduled, partiallySucceeded
+	CreatedBy               string
+	MinCreatedTime          time.Time
+	MaxCreatedTime          time.Time
+	SourceBranchFilter      string
+	ArtifactTypeId          string // e.g. Build
+	ArtifactVersionId       string // for Build artifacts, the build ID
+	SearchText              string
+	ExpandEnvironments      bool
+}
+
+func (q ReleaseQuery) values() (url.Values, error) {
+	v := url.Values{}
+	v.Set("api-version", "6.0")
+	if q.Top > 0 {
+		v.Set("$top", strconv.Itoa(q.Top))
+	}
+	if q.ContinuationToken != "" {
+		v.Set("continuationToken", q.ContinuationToken)
+	}
+	if q.DefinitionId > 0 {
+		v.Set("definitionId", strconv.Itoa(q.DefinitionId))
+	}
+	if q.DefinitionEnvironmentId > 0 {
+		v.Set("definitionEnvironmentId", strconv.Itoa(q.DefinitionEnvironmentId))
+	}
+	if q.StatusFilter != "" {
+		v.Set("statusFilter", q.StatusFilter)
+	}
+	if len(q.EnvironmentStatusFilter) > 0 {
+		flags := 0
+		for _, status := range q.EnvironmentStatusFilter {
+			flag, ok := environmentStatusFlags[status]
+			if !ok {
+				return nil, fmt.Errorf("unknown environment status %q", status)
+			}
+			flags |= flag
+		}
+		v.Set("environmentStatusFilter", strconv.Itoa(flags))
+	}
+	if q.CreatedBy != "" {
+		v.Set("createdBy", q.CreatedBy)
+	}
+	if !q.MinCreatedTime.IsZero() {
+		v.Set("minCreatedTime", q.MinCreatedTime.UTC().Format(time.RFC3339))
+	}
+	if !q.MaxCreatedTime.IsZero() {
+		v.Set("maxCreatedTime", q.MaxCreatedTime.UTC().Format(time.RFC3339))
+	}
+	if q.SourceBranchFilter != "" {
+		v.Set("sourceBranchFilter", q.SourceBranchFilter)
+	}
+	if q.ArtifactTypeId != "" {
+		v.Set("artifactTypeId", q.ArtifactTypeId)
+	}
+	if q.ArtifactVersionId != "" {
+		v.Set("artifactVersionId", q.ArtifactVersionId)
+	}
+	if q.SearchText != "" {
+		v.Set("searchText", q.SearchText)
+	}
+	if q.ExpandEnvironments {
+		v.Set("$expand", "environments")
+	}
+	return v, nil
 }
 
-func (c *Client) GetReleases(project string, top int) ([]Release, error) {
+// GetReleases lists the releases matching q.
+func (c *Client) GetReleases(ctx context.Context, project string, q ReleaseQuery) (*ReleasePage, error) {
 	// Release API is often under vsrm subdomain for cloud, but for on-prem it might be different.
 	// Usually: https://server/collection/project/_apis/release/releases
 	// We'll assume the base URL structure handles the routing or we adjust the path if needed.
 	// For on-prem, it is often just /_apis/release/releases
 	
-	path := fmt.Sprintf("release/releases?api-version=6.0&$top=%d", top)
+	values, err := q.values()
+	if err != nil {
+		return nil, err
+	}
+	path := "release/releases?" + values.Encode()
 	
 	// Note: Release API might need a different base URL logic if it's strictly separated, 
 	// but for on-prem single server, it's usually under the same collection.
 	
-	req, err := c.getRequest(project, path)
+	req, err := c.getRequest(ctx, project, path)
 	if err != nil {
 		return nil, err
 	}
 
 	var response ReleaseListResponse
-	if err := c.doRequest(req, &response); err != nil {
+	token, err := c.doRequestPage(req, &response, cachePolicy{list: true})
+	if err != nil {
 		return nil, err
 	}
-	return response.Value, nil
+	return &ReleasePage{Releases: response.Value, ContinuationToken: token}, nil
 }
 
-func (c *Client) GetRelease(project string, releaseId int) (*Release, error) {
+func (c *Client) GetRelease(ctx context.Context, project string, releaseId int) (*Release, error) {
 	path := fmt.Sprintf("release/releases/%d?api-version=6.0", releaseId)
-	req, err := c.getRequest(project, path)
+	req, err := c.getRequest(ctx, project, path)
 	if err != nil {
 		return nil, err
 	}
 
 	var release Release
-	if err := c.doRequest(req, &release); err != nil {
+	if err := c.doRequest(req, &release, cachePolicy{}); err != nil {
 		return nil, err
 	}
 	return &release, nil
 }
 
+// releaseDetail is the part of a release needed to find its task logs.
+type releaseDetail struct {
+	Environments []struct {
+		Id          int    `json:"id"`
+		Name        string `json:"name"`
+		Status      string `json:"status"`
+		DeploySteps []struct {
+			ReleaseDeployPhases []struct {
+				DeploymentJobs []struct {
+					Tasks []releaseTask `json:"tasks"`
+				} `json:"deploymentJobs"`
+			} `json:"releaseDeployPhases"`
+		} `json:"deploySteps"`
+	} `json:"environments"`
+}
+
+type releaseTask struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	LogUrl string `json:"logUrl"`
+	Issues []struct {
+		IssueType string            `json:"issueType"`
+		Message   string            `json:"message"`
+		Data      map[string]string `json:"data"`
+	} `json:"issues"`
+}
+
+// tasks returns the tasks of an environment in execution order.
+func (d *releaseDetail) tasks(env int) []releaseTask {
+	var tasks []releaseTask
+	for _, step := range d.Environments[env].DeploySteps {
+		for _, phase := range step.ReleaseDeployPhases {
+			for _, job := range phase.DeploymentJobs {
+				tasks = append(tasks, job.Tasks...)
+			}
+		}
+	}
+	return tasks
+}
+
+func (c *Client) getReleaseDetail(ctx context.Context, project string, releaseId int) (*releaseDetail, error) {
+	path := fmt.Sprintf("release/releases/%d?api-version=6.0", releaseId)
+	req, err := c.getRequest(ctx, project, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var detail releaseDetail
+	if err := c.doRequest(req, &detail, cachePolicy{}); err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// getReleaseTaskLog fetches a release task log from its logUrl. The log of
+// a finished task is cached for good.
+func (c *Client) getReleaseTaskLog(ctx context.Context, logUrl string, finished bool) (string, error) {
+	// The LogUrl is usually a full URL. We need to fetch it.
+	// It might be absolute.
+	logReq, err := http.NewRequestWithContext(ctx, "GET", logUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(":" + c.Token))
+	logReq.Header.Add("Authorization", "Basic "+auth)
+
+	content, err := c.doRequestRaw(logReq, cachePolicy{final: finished})
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
 // GetReleaseLogs is more complex as it involves environments and tasks.
 // Simplified version to get logs for all environments.
-func (c *Client) GetReleaseLogs(project string, releaseId int) (string, error) {
+func (c *Client) GetReleaseLogs(ctx context.Context, project string, releaseId int) (string, error) {
 	// Fetch release details to get environment IDs
-	path := fmt.Sprintf("release/releases/%d?api-version=6.0", releaseId)
-	req, err := c.getRequest(project, path)
+	detail, err := c.getReleaseDetail(ctx, project, releaseId)
 	if err != nil {
 		return "", err
 	}
-	
-	// We need a more detailed struct to parse environments for logs
-	type ReleaseDetail struct {
-		Environments []struct {
-			Id int `json:"id"`
-			Name string `json:"name"`
-			DeploySteps []struct {
-				ReleaseDeployPhases []struct {
-					DeploymentJobs []struct {
-						Tasks []struct {
-							Id int `json:"id"`
-							Name string `json:"name"`
-							LogUrl string `json:"logUrl"`
-						} `json:"tasks"`
-					} `json:"deploymentJobs"`
-				} `json:"releaseDeployPhases"`
-			} `json:"deploySteps"`
-		} `json:"environments"`
-	}
-
-	var detail ReleaseDetail
-	if err := c.doRequest(req, &detail); err != nil {
+
+	// Collect the task logs of all environments so they can be fetched in
+	// parallel, then write them out environment by environment
+	type taskLog struct {
+		env      int
+		name     string
+		logUrl   string
+		finished bool
+	}
+	var tasks []taskLog
+	for i := range detail.Environments {
+		for _, task := range detail.tasks(i) {
+			if task.LogUrl != "" {
+				tasks = append(tasks, taskLog{env: i, name: task.Name, logUrl: task.LogUrl, finished: finishedStatus(task.Status)})
+			}
+		}
+	}
+
+	envDone := make([]int, len(detail.Environments))
+	envTotals := make([]int, len(detail.Environments))
+	for _, task := range tasks {
+		envTotals[task.env]++
+	}
+	label := func(i int) string {
+		env := tasks[i].env
+		envDone[env]++
+		return fmt.Sprintf("environment %s, task %d of %d", detail.Environments[env].Name, envDone[env], envTotals[env])
+	}
+	results, err := c.fetchLogs(ctx, len(tasks), label, func(ctx context.Context, i int) (string, error) {
+		return c.getReleaseTaskLog(ctx, tasks[i].logUrl, tasks[i].finished)
+	})
+	if err != nil {
 		return "", err
 	}
 
 	var fullLogs strings.Builder
-	
-	for _, env := range detail.Environments {
+	next := 0
+	for i, env := range detail.Environments {
 		fullLogs.WriteString(fmt.Sprintf("=== Environment: %s ===\n", env.Name))
-		for _, step := range env.DeploySteps {
-			for _, phase := range step.ReleaseDeployPhases {
-				for _, job := range phase.DeploymentJobs {
-					for _, task := range job.Tasks {
-						if task.LogUrl == "" {
-							continue
-						}
-						
-						// The LogUrl is usually a full URL. We need to fetch it.
-						// It might be absolute.
-						logReq, err := http.NewRequest("GET", task.LogUrl, nil)
-						if err != nil {
-							continue
-						}
-						auth := base64.StdEncoding.EncodeToString([]byte(":" + c.Token))
-						logReq.Header.Add("Authorization", "Basic "+auth)
-						
-						resp, err := c.HTTPClient.Do(logReq)
-						if err != nil {
-							continue
-						}
-						
-						content, _ := io.ReadAll(resp.Body)
-						resp.Body.Close()
-						
-						fullLogs.WriteString(fmt.Sprintf("--- Task: %s ---\n", task.Name))
-						fullLogs.Write(content)
-						fullLogs.WriteString("\n")
-					}
-				}
-			}
+		for ; next < len(tasks) && tasks[next].env == i; next++ {
+			fullLogs.WriteString(fmt.Sprintf("--- Task: %s ---\n", tasks[next].name))
+			writeLogResult(&fullLogs, results[next])
 		}
 	}
 
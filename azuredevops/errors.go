@@ -0,0 +1,81 @@
+package azuredevops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError is an error response from Azure DevOps. TypeKey, ErrorCode and
+// Message come from the JSON error payload when there is one; otherwise
+// Message is the HTTP status text, so that HTML error pages never end up in
+// error messages.
+type APIError struct {
+	StatusCode int
+	TypeKey    string // e.g. BuildNotFoundException
+	ErrorCode  int
+	Message    string
+	URL        string
+	ActivityID string // identifies the request in the server logs
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Message)
+	if e.TypeKey != "" {
+		msg += " (" + e.TypeKey + ")"
+	}
+	return msg
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		URL:        resp.Request.URL.Redacted(),
+		ActivityID: resp.Header.Get("ActivityId"),
+	}
+	if e.ActivityID == "" {
+		e.ActivityID = resp.Header.Get("X-VSS-E2EID")
+	}
+
+	var payload struct {
+		Message   string `json:"message"`
+		TypeKey   string `json:"typeKey"`
+		ErrorCode int    `json:"errorCode"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Message != "" {
+		e.Message, e.TypeKey, e.ErrorCode = payload.Message, payload.TypeKey, payload.ErrorCode
+	} else {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+func apiStatus(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is an Azure DevOps 404 response.
+func IsNotFound(err error) bool {
+	return apiStatus(err) == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether Azure DevOps rejected the credentials or
+// denied access: a 401 or 403 response, or a 203 sign-in page.
+func IsUnauthorized(err error) bool {
+	switch apiStatus(err) {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNonAuthoritativeInfo:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsThrottled reports whether err is an Azure DevOps 429 response.
+func IsThrottled(err error) bool {
+	return apiStatus(err) == http.StatusTooManyRequests
+}
@@ -0,0 +1,72 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// defaultConcurrency is the number of logs fetched in parallel when
+// Client.Concurrency is not set.
+const defaultConcurrency = 4
+
+// logResult is the outcome of fetching one log.
+type logResult struct {
+	content string
+	err     error
+}
+
+// fetchLogs calls fetch for the indexes 0 to n-1, with at most
+// c.Concurrency calls in flight, and returns the results in index order.
+// Progress is reported as calls complete, with the message label(i) for
+// call i, or "log N of M" if label is nil. label is called under a lock, in
+// order of completion. Once ctx is done no further calls are started and
+// ctx.Err() is returned.
+func (c *Client) fetchLogs(ctx context.Context, n int, label func(i int) string, fetch func(ctx context.Context, i int) (string, error)) ([]logResult, error) {
+	results := make([]logResult, n)
+	workers := c.Concurrency
+	if workers <= 0 {
+		workers = defaultConcurrency
+	}
+	workers = min(workers, n)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex // serializes progress so that it only grows
+	done := 0
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				content, err := fetch(ctx, i)
+				results[i] = logResult{content: content, err: err}
+
+				mu.Lock()
+				done++
+				message := fmt.Sprintf("log %d of %d", done, n)
+				if label != nil {
+					message = label(i)
+				}
+				reportProgress(ctx, done, n, message)
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,52 @@
+package azuredevops
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup coalesces concurrent calls with the same key, so that a
+// request asked for by several sessions at once is sent only once.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a call in progress, whose result is shared by every caller
+// once done is closed.
+type flight struct {
+	done  chan struct{}
+	entry *CacheEntry
+	err   error
+}
+
+// do calls fn, unless a call with the same key is already in progress, in
+// which case it waits for that call and returns its result instead, with
+// shared set. A waiting caller stops waiting once ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (*CacheEntry, error)) (entry *CacheEntry, shared bool, err error) {
+	g.mu.Lock()
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.entry, true, f.err
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	if g.flights == nil {
+		g.flights = map[string]*flight{}
+	}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.entry, f.err = fn()
+	return f.entry, false, f.err
+}
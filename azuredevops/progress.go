@@ -0,0 +1,21 @@
+package azuredevops
+
+import "context"
+
+// ProgressFunc receives progress of long-running client calls, such as
+// fetching every log of a build. total is 0 when unknown.
+type ProgressFunc func(done, total int, message string)
+
+type progressKey struct{}
+
+// WithProgress returns a context that makes client calls report their
+// progress to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func reportProgress(ctx context.Context, done, total int, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(done, total, message)
+	}
+}
@@ -0,0 +1,108 @@
+package azuredevops
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+)
+
+// Redactor replaces secrets in text with a "[REDACTED:kind]" marker. It
+// knows common credential formats and can be given literal secrets and
+// extra patterns. Newlines inside a redacted secret are kept, so that line
+// numbers of redacted logs still match the originals.
+type Redactor struct {
+	rules []redactRule
+}
+
+// redactRule redacts the matches of re. When re has a capture group, the
+// match is kept up to the end of the first group, e.g. the key of a
+// key=value pair.
+type redactRule struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// builtinRedactRules are checked before any configured pattern. Value
+// patterns stop at quotes and backslashes so that redacting JSON text
+// leaves it valid; private keys may span escaped line breaks, as in JSON.
+var builtinRedactRules = []redactRule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----(?:[^"\\]|\\[nr])*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`)},
+	{"connection-string", regexp.MustCompile(`(?i)(\b(?:password|pwd|accountkey|sharedaccesskey|sharedaccesssignature|clientsecret|client_secret)\s*=\s*)[^;\s"'\\]+`)},
+	{"sas-token", regexp.MustCompile(`(?i)([?&]sig=)[A-Za-z0-9%+/=]{16,}`)},
+	{"authorization", regexp.MustCompile(`(?i)(\bauthorization["']?\s*[:=]\s*["']?(?:bearer|basic)\s+)[A-Za-z0-9._~+/-]{16,}=*`)},
+	{"storage-key", regexp.MustCompile(`\b[A-Za-z0-9+/]{86}==`)},
+	{"ado-pat", regexp.MustCompile(`\b[A-Za-z0-9]{76}AZDO[A-Za-z0-9]{4}\b`)},
+	{"ado-pat", regexp.MustCompile(`\b[a-z2-7]{52}\b`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+}
+
+// minSecretLength keeps short literal secrets, which would match all over
+// a log, from being redacted.
+const minSecretLength = 8
+
+// NewRedactor returns a Redactor with the built-in detectors that also
+// redacts the given literal secrets, as well as their base64 Basic
+// authentication form.
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{rules: append([]redactRule(nil), builtinRedactRules...)}
+	for _, secret := range secrets {
+		if len(secret) < minSecretLength {
+			continue
+		}
+		basic := base64.StdEncoding.EncodeToString([]byte(":" + secret))
+		r.rules = append(r.rules, redactRule{
+			kind: "secret",
+			re:   regexp.MustCompile(regexp.QuoteMeta(secret) + "|" + regexp.QuoteMeta(basic)),
+		})
+	}
+	return r
+}
+
+// AddPattern adds a regular expression whose matches are redacted. If it
+// has a capture group, the match is kept up to the end of the first group.
+func (r *Redactor) AddPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	r.rules = append(r.rules, redactRule{kind: "custom", re: re})
+	return nil
+}
+
+// Redact returns text with every secret replaced. A nil Redactor returns
+// text unchanged.
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, rule := range r.rules {
+		text = rule.redact(text)
+	}
+	return text
+}
+
+func (rule redactRule) redact(text string) string {
+	matches := rule.re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		start := m[0]
+		if len(m) >= 4 && m[2] >= 0 {
+			// Keep the first group, e.g. "Password="
+			out.WriteString(text[last:m[3]])
+			start = m[3]
+		} else {
+			out.WriteString(text[last:m[0]])
+		}
+		out.WriteString("[REDACTED:" + rule.kind + "]")
+		out.WriteString(strings.Repeat("\n", strings.Count(text[start:m[1]], "\n")))
+		last = m[1]
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
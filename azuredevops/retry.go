@@ -0,0 +1,101 @@
+package azuredevops
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests that fail transiently are retried:
+// network errors and 429, 502, 503 and 504 responses. Delays grow
+// exponentially with jitter, unless the server asks for a specific delay
+// with Retry-After.
+type RetryPolicy struct {
+	MaxAttempts int           // attempts per request, including the first; 1 disables retries
+	BaseDelay   time.Duration // delay before the first retry, doubled for every further one
+	MaxDelay    time.Duration // upper bound of a computed delay
+	MaxElapsed  time.Duration // total time a request may take, retries included; 0 means no limit
+}
+
+// DefaultRetryPolicy is the policy set by NewClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	MaxElapsed:  2 * time.Minute,
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before retry number n, counting from 1.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay << (n - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	// Jitter spreads out retries of requests that failed together
+	return d/2 + rand.N(d/2+1)
+}
+
+// serverDelay returns how long the server asks clients to wait: the
+// Retry-After header, or X-RateLimit-Delay once the rate limit is used up.
+func serverDelay(resp *http.Response) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if secs, err := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Delay"), 64); err == nil {
+			return time.Duration(secs * float64(time.Second))
+		}
+	}
+	return 0
+}
+
+// throttle delays all requests of the client by d from now, as asked by a
+// rate-limited response.
+func (c *Client) throttle(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+	if until := time.Now().Add(d); until.After(c.notBefore) {
+		c.notBefore = until
+	}
+}
+
+// waitThrottle blocks until the client is no longer throttled.
+func (c *Client) waitThrottle(ctx context.Context) error {
+	c.throttleMu.Lock()
+	d := time.Until(c.notBefore)
+	c.throttleMu.Unlock()
+	return sleep(ctx, d)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Timeline is the record tree of a build: stages contain phases, phases
+// contain jobs and jobs contain tasks. Records refer to their parent by ID.
+type Timeline struct {
+	Id      string           `json:"id"`
+	Records []TimelineRecord `json:"records"`
+}
+
+type TimelineRecord struct {
+	Id           string          `json:"id"`
+	ParentId     string          `json:"parentId"`
+	Type         string          `json:"type"` // Stage, Phase, Job, Task, Checkpoint, ...
+	Name         string          `json:"name"`
+	Order        int             `json:"order"`
+	State        string          `json:"state"`  // pending, inProgress, completed
+	Result       string          `json:"result"` // succeeded, succeededWithIssues, failed, canceled, skipped, abandoned
+	StartTime    *time.Time      `json:"startTime"`
+	FinishTime   *time.Time      `json:"finishTime"`
+	ErrorCount   int             `json:"errorCount"`
+	WarningCount int             `json:"warningCount"`
+	Issues       []TimelineIssue `json:"issues"`
+	Log          *struct {
+		Id int `json:"id"`
+	} `json:"log"`
+}
+
+type TimelineIssue struct {
+	Type     string            `json:"type"` // error or warning
+	Category string            `json:"category"`
+	Message  string            `json:"message"`
+	Data     map[string]string `json:"data"` // e.g. logFileLineNumber
+}
+
+// Duration is the time the record took, or 0 if it has not finished.
+func (r *TimelineRecord) Duration() time.Duration {
+	if r.StartTime == nil || r.FinishTime == nil {
+		return 0
+	}
+	return r.FinishTime.Sub(*r.StartTime)
+}
+
+// LogId is the ID of the record's log, or 0 if it has none.
+func (r *TimelineRecord) LogId() int {
+	if r.Log == nil {
+		return 0
+	}
+	return r.Log.Id
+}
+
+// Children returns the records whose parent is parentId, in execution order.
+// An empty parentId returns the root records, including those whose parent
+// is not part of the timeline.
+func (t *Timeline) Children(parentId string) []TimelineRecord {
+	ids := make(map[string]bool, len(t.Records))
+	for _, r := range t.Records {
+		ids[r.Id] = true
+	}
+
+	var children []TimelineRecord
+	for _, r := range t.Records {
+		if r.ParentId == parentId || (parentId == "" && !ids[r.ParentId]) {
+			children = append(children, r)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].Order < children[j].Order
+	})
+	return children
+}
+
+// failedRecords returns the records with a log that failed or were
+// canceled, in execution order. Each is preceded by its enclosing job, if
+// that has a log, and no record is returned twice.
+func (t *Timeline) failedRecords() []TimelineRecord {
+	var failed []TimelineRecord
+	seen := map[string]bool{}
+	add := func(r TimelineRecord) {
+		if r.LogId() > 0 && !seen[r.Id] {
+			seen[r.Id] = true
+			failed = append(failed, r)
+		}
+	}
+
+	var walk func(parentId string, job *TimelineRecord)
+	walk = func(parentId string, job *TimelineRecord) {
+		for _, r := range t.Children(parentId) {
+			if r.Result == "failed" || r.Result == "canceled" {
+				if job != nil {
+					add(*job)
+				}
+				add(r)
+			}
+			if r.Id == "" {
+				continue
+			}
+			if r.Type == "Job" {
+				walk(r.Id, &r)
+			} else {
+				walk(r.Id, job)
+			}
+		}
+	}
+	walk("", nil)
+
+	return failed
+}
+
+func (c *Client) GetBuildTimeline(ctx context.Context, project string, buildId int) (*Timeline, error) {
+	path := fmt.Sprintf("build/builds/%d/timeline?api-version=6.0", buildId)
+	req, err := c.getRequest(ctx, project, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeline Timeline
+	if err := c.doRequest(req, &timeline, cachePolicy{}); err != nil {
+		return nil, err
+	}
+	return &timeline, nil
+}
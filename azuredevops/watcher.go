@@ -0,0 +1,101 @@
+package azuredevops
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// BuildWatcher polls a set of builds and reports every change of their
+// status or result. Completed builds are no longer polled.
+type BuildWatcher struct {
+	client   *Client
+	interval time.Duration
+	onChange func(project string, build *Build)
+
+	mu      sync.Mutex
+	watched map[watchKey]*Build
+}
+
+type watchKey struct {
+	project string
+	buildId int
+}
+
+func NewBuildWatcher(client *Client, interval time.Duration, onChange func(project string, build *Build)) *BuildWatcher {
+	return &BuildWatcher{
+		client:   client,
+		interval: interval,
+		onChange: onChange,
+		watched:  make(map[watchKey]*Build),
+	}
+}
+
+// Watch starts tracking a build. The current state is fetched right away so
+// that unknown builds are rejected and the first change is not missed.
+func (w *BuildWatcher) Watch(ctx context.Context, project string, buildId int) error {
+	build, err := w.client.GetBuild(ctx, project, buildId)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.watched[watchKey{project, buildId}] = build
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *BuildWatcher) Unwatch(project string, buildId int) {
+	w.mu.Lock()
+	delete(w.watched, watchKey{project, buildId})
+	w.mu.Unlock()
+}
+
+// Run polls the watched builds until ctx is done.
+func (w *BuildWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.poll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *BuildWatcher) poll(ctx context.Context) {
+	w.mu.Lock()
+	pending := make(map[watchKey]*Build, len(w.watched))
+	for key, last := range w.watched {
+		if last.Status != "completed" {
+			pending[key] = last
+		}
+	}
+	w.mu.Unlock()
+
+	for key, last := range pending {
+		build, err := w.client.GetBuild(ctx, key.project, key.buildId)
+		if err != nil {
+			log.Printf("Failed to poll build %d: %v", key.buildId, err)
+			continue
+		}
+		if build.Status == last.Status && build.Result == last.Result {
+			continue
+		}
+
+		w.mu.Lock()
+		_, stillWatched := w.watched[key]
+		if stillWatched {
+			w.watched[key] = build
+		}
+		w.mu.Unlock()
+
+		if stillWatched {
+			w.onChange(key.project, build)
+		}
+	}
+}
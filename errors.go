@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yildizozan/adomcp/azuredevops"
+	"github.com/yildizozan/adomcp/mcp"
+)
+
+// describeError turns Azure DevOps API errors into short messages that tell
+// the user what to do. Other errors are returned unchanged.
+func describeError(err error) error {
+	var apiErr *azuredevops.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	var msg string
+	switch {
+	case azuredevops.IsNotFound(err):
+		msg = fmt.Sprintf("Not found in Azure DevOps: %s. Check the ID and the project.", strings.TrimSuffix(apiErr.Message, "."))
+	case azuredevops.IsUnauthorized(err):
+		msg = fmt.Sprintf("Azure DevOps denied access (HTTP %d). Check that ADO_TOKEN is valid and can read builds and releases of the project.", apiErr.StatusCode)
+	case azuredevops.IsThrottled(err):
+		msg = "Azure DevOps is rate limiting requests (HTTP 429). Try again in a few minutes."
+	default:
+		msg = fmt.Sprintf("Azure DevOps request failed (HTTP %d): %s", apiErr.StatusCode, apiErr.Message)
+	}
+	if apiErr.ActivityID != "" {
+		msg += fmt.Sprintf(" [activity ID %s]", apiErr.ActivityID)
+	}
+	return errors.New(msg)
+}
+
+// resourceError is describeError for resource handlers, which report
+// missing builds and releases as unknown resources.
+func resourceError(err error) error {
+	if azuredevops.IsNotFound(err) {
+		return mcp.ErrResourceNotFound
+	}
+	return describeError(err)
+}
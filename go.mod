@@ -3,6 +3,6 @@ module github.com/yildizozan/adomcp
 go 1.25.4
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/joho/godotenv v1.5.1
 )
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yildizozan/adomcp/azuredevops"
+	"github.com/yildizozan/adomcp/mcp"
+)
+
+// splitLines splits log content into lines, dropping the trailing newline
+// and carriage returns.
+func splitLines(content string) []string {
+	content = strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if content == "" {
+		return nil
+	}
+	return strings.Split(content, "\n")
+}
+
+// numberLines prefixes every line with its line number, the first line
+// being firstLine.
+func numberLines(lines []string, firstLine int) string {
+	var out strings.Builder
+	for i, line := range lines {
+		out.WriteString(fmt.Sprintf("%d: %s\n", firstLine+i, line))
+	}
+	return out.String()
+}
+
+// grepLines returns the lines matching re, numbered from firstLine, with
+// contextLines lines of context around each match. As in grep, matches are
+// marked "N:", context lines "N-", and non-adjacent groups are separated by
+// "--". At most maxMatches matches are returned when maxMatches > 0.
+func grepLines(lines []string, firstLine int, re *regexp.Regexp, contextLines, maxMatches int) (string, int) {
+	var out strings.Builder
+	matches := 0
+	next := 0 // first line not yet written
+
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		if maxMatches > 0 && matches == maxMatches {
+			break
+		}
+		matches++
+
+		from := max(i-contextLines, next)
+		if next > 0 && from > next {
+			out.WriteString("--\n")
+		}
+		to := min(i+contextLines, len(lines)-1)
+		for j := from; j <= to; j++ {
+			sep := "-"
+			if re.MatchString(lines[j]) {
+				sep = ":"
+			}
+			out.WriteString(fmt.Sprintf("%d%s %s\n", firstLine+j, sep, lines[j]))
+		}
+		next = to + 1
+	}
+
+	return out.String(), matches
+}
+
+// isSectionHeader reports whether a line starts a section of concatenated
+// logs, such as "--- Log ID 5 ---" or "=== Environment: Prod ===".
+func isSectionHeader(line string) bool {
+	return (strings.HasPrefix(line, "--- ") && strings.HasSuffix(line, " ---")) ||
+		(strings.HasPrefix(line, "=== ") && strings.HasSuffix(line, " ==="))
+}
+
+// lineRange is a half-open range [Start, End) of lines of a log output.
+type lineRange struct {
+	Start, End int
+}
+
+// logView is a log output split into lines, with the section each line
+// falls under.
+type logView struct {
+	lines   []string
+	section []int // index of the section header of each line, or -1
+	end     []int // index of the line after the section of each line
+}
+
+func newLogView(logs string) *logView {
+	v := &logView{lines: splitLines(logs)}
+	v.section = make([]int, len(v.lines))
+	v.end = make([]int, len(v.lines))
+
+	header := -1
+	for i, line := range v.lines {
+		if isSectionHeader(line) {
+			header = i
+		}
+		v.section[i] = header
+	}
+	end := len(v.lines)
+	for i := len(v.lines) - 1; i >= 0; i-- {
+		v.end[i] = end
+		if v.section[i] == i {
+			end = i
+		}
+	}
+	return v
+}
+
+// truncateLogs fits a log output into budget characters. It keeps every
+// section header, every error line, and the head and tail of each section,
+// with tails twice as long as heads since failures are reported at the end.
+// The omitted lines are returned so that they can be fetched later with
+// continueLogs.
+func truncateLogs(logs string, budget int) (string, []lineRange) {
+	if len(logs) <= budget {
+		return logs, nil
+	}
+
+	v := newLogView(logs)
+	isError := make([]bool, len(v.lines))
+	for i, line := range v.lines {
+		isError[i] = azuredevops.LineSeverity(line) == "error"
+	}
+	keep := make([]bool, len(v.lines))
+	keepHeadTail := func(head int) {
+		for i := range v.lines {
+			start := v.section[i] + 1
+			keep[i] = v.section[i] == i || isError[i] ||
+				i-start < head || v.end[i]-i <= 2*head
+		}
+	}
+
+	// Find the longest head that fits. Sizes only grow with the head, so a
+	// binary search is enough.
+	lo, hi := 0, len(v.lines)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		keepHeadTail(mid)
+		if v.size(keep) <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	keepHeadTail(lo)
+	return v.render(keep, budget)
+}
+
+// omittedMarker stands in for n omitted lines.
+func omittedMarker(n int) string {
+	return fmt.Sprintf("[... %d lines omitted ...]\n", n)
+}
+
+// omittedMarkerLen is len(omittedMarker(n)).
+func omittedMarkerLen(n int) int {
+	return len("[...  lines omitted ...]\n") + len(strconv.Itoa(n))
+}
+
+// size returns the length of the output of render for keep, without a
+// budget.
+func (v *logView) size(keep []bool) int {
+	size, omitted := 0, 0
+	for i, line := range v.lines {
+		if !keep[i] {
+			omitted++
+			continue
+		}
+		if omitted > 0 {
+			size += omittedMarkerLen(omitted)
+			omitted = 0
+		}
+		size += len(line) + 1
+	}
+	if omitted > 0 {
+		size += omittedMarkerLen(omitted)
+	}
+	return size
+}
+
+// render writes the kept lines, replacing each run of omitted lines with a
+// marker. If the result would exceed budget, the output is cut at a line
+// boundary and the rest is omitted as well.
+func (v *logView) render(keep []bool, budget int) (string, []lineRange) {
+	var out strings.Builder
+	var omitted []lineRange
+	// Unless everything fits, writing a line must leave room for the marker
+	// of a later cut
+	cutLen := omittedMarkerLen(len(v.lines))
+	if v.size(keep) <= budget {
+		budget, cutLen = math.MaxInt, 0
+	}
+
+	for i := 0; i < len(v.lines); {
+		j := i
+		for j < len(v.lines) && !keep[j] {
+			j++
+		}
+		markerLen := 0
+		if j > i {
+			markerLen = omittedMarkerLen(j - i)
+		}
+
+		if j < len(v.lines) && out.Len()+markerLen+len(v.lines[j])+1+cutLen > budget {
+			out.WriteString(omittedMarker(len(v.lines) - i))
+			return out.String(), append(omitted, lineRange{i, len(v.lines)})
+		}
+		if j > i {
+			out.WriteString(omittedMarker(j - i))
+			omitted = append(omitted, lineRange{i, j})
+		}
+		if j < len(v.lines) {
+			out.WriteString(v.lines[j] + "\n")
+		}
+		i = j + 1
+	}
+
+	return out.String(), omitted
+}
+
+// continueLogs returns the lines in ranges, as far as they fit into budget
+// characters, and the ranges that remain. Each range is introduced by the
+// line it continues at, and by its section header when the section changes.
+func continueLogs(logs string, ranges []lineRange, budget int) (string, []lineRange) {
+	v := newLogView(logs)
+	var out strings.Builder
+	lastSection := -1
+
+	for k, r := range ranges {
+		r.End = min(r.End, len(v.lines))
+		for i := r.Start; i < r.End; i++ {
+			var intro string
+			if header := v.section[i]; i == r.Start && header != i {
+				if header >= 0 && header != lastSection {
+					intro = v.lines[header] + "\n"
+				}
+				intro += fmt.Sprintf("[... continuing at line %d ...]\n", i-header)
+			}
+
+			line := v.lines[i] + "\n"
+			// Always make progress, even if a single line exceeds the budget
+			if out.Len() > 0 && out.Len()+len(intro)+len(line) > budget {
+				rest := append([]lineRange{{i, r.End}}, ranges[k+1:]...)
+				return out.String(), rest
+			}
+			out.WriteString(intro + line)
+			lastSection = v.section[i]
+		}
+	}
+
+	return out.String(), nil
+}
+
+// limitLogs fits logs into maxOutput characters for resources and prompts,
+// which cannot be paged with a cursor. If lines are omitted, a note points
+// to the tool that can page through them.
+func limitLogs(logs string, maxOutput int, tool string) string {
+	note := fmt.Sprintf("\n[Output limited to %d characters. Use the %s tool to page through the omitted lines.]\n", maxOutput, tool)
+	out, rest := truncateLogs(logs, maxOutput-len(note))
+	if len(rest) == 0 {
+		return out
+	}
+	return out + note
+}
+
+// defaultMaxOutput is the default size limit, in characters, of the text
+// returned by the log tools: about 25k tokens.
+const defaultMaxOutput = 100000
+
+// logOutputArgs are the output size arguments shared by the log tools.
+type logOutputArgs struct {
+	MaxOutput int    `json:"maxOutput,omitempty" description:"Maximum number of characters to return (default set by the server). Longer output keeps the head and tail of each log and every error line" jsonschema:"minimum=1000"`
+	Cursor    string `json:"cursor,omitempty" description:"Cursor from a previous truncated call with the same arguments, to fetch the omitted lines"`
+}
+
+// limit fits logs, footer included, into the output budget. Without a
+// cursor, the logs are truncated; with one, the omitted lines it refers to
+// are returned. Either way, a footer with a new cursor is added if lines
+// remain.
+func (a logOutputArgs) limit(logs string, defaultMax int) (string, error) {
+	maxOutput := a.MaxOutput
+	if maxOutput == 0 {
+		maxOutput = defaultMax
+	}
+	var ranges []lineRange
+	if a.Cursor != "" {
+		var err error
+		if ranges, err = decodeLogCursor(a.Cursor); err != nil {
+			return "", err
+		}
+	}
+
+	// The footer depends on what is left out, so start with the room a
+	// short one needs and make more until the footer fits
+	reserve := len(logFooter(maxOutput, []lineRange{{0, 1}}))
+	for {
+		var out string
+		var rest []lineRange
+		if a.Cursor == "" {
+			out, rest = truncateLogs(logs, maxOutput-reserve)
+		} else {
+			out, rest = continueLogs(logs, ranges, maxOutput-reserve)
+		}
+		footer := logFooter(maxOutput, rest)
+		if len(out)+len(footer) <= maxOutput || reserve >= maxOutput {
+			return out + footer, nil
+		}
+		reserve += len(out) + len(footer) - maxOutput
+	}
+}
+
+// logFooter tells how to fetch the lines in rest, if any.
+func logFooter(maxOutput int, rest []lineRange) string {
+	if len(rest) == 0 {
+		return ""
+	}
+	omitted := 0
+	for _, r := range rest {
+		omitted += r.End - r.Start
+	}
+	return fmt.Sprintf("\n[Output limited to %d characters, %d lines omitted. Call again with the same arguments and cursor %q to fetch them.]\n",
+		maxOutput, omitted, encodeLogCursor(rest))
+}
+
+// encodeLogCursor packs line ranges into an opaque cursor. Ranges are
+// stored as varint gaps and lengths to keep cursors short.
+func encodeLogCursor(ranges []lineRange) string {
+	var buf []byte
+	prev := 0
+	for _, r := range ranges {
+		buf = binary.AppendUvarint(buf, uint64(r.Start-prev))
+		buf = binary.AppendUvarint(buf, uint64(r.End-r.Start))
+		prev = r.End
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodeLogCursor(cursor string) ([]lineRange, error) {
+	invalid := &mcp.ArgumentError{Field: "cursor", Reason: "is not a valid cursor"}
+
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(buf) == 0 {
+		return nil, invalid
+	}
+	var ranges []lineRange
+	prev := 0
+	for len(buf) > 0 {
+		gap, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, invalid
+		}
+		buf = buf[n:]
+		length, n := binary.Uvarint(buf)
+		if n <= 0 || gap > math.MaxInt32 || length > math.MaxInt32 {
+			return nil, invalid
+		}
+		buf = buf[n:]
+
+		r := lineRange{Start: prev + int(gap)}
+		r.End = r.Start + int(length)
+		ranges = append(ranges, r)
+		prev = r.End
+	}
+	return ranges, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// testLogs returns n concatenated logs of the given number of lines, with
+// an error line in the middle of every log.
+func testLogs(n, lines int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "--- Log ID %d ---\n", i)
+		for j := 1; j <= lines; j++ {
+			if j == lines/2 {
+				fmt.Fprintf(&b, "##[error]log %d line %d failed\n", i, j)
+			} else {
+				fmt.Fprintf(&b, "log %d line %d\n", i, j)
+			}
+		}
+	}
+	return b.String()
+}
+
+var cursorPattern = regexp.MustCompile(`cursor "([A-Za-z0-9_-]+)"`)
+
+// pages follows the cursors of limit until no lines remain.
+func pages(t *testing.T, logs string, maxOutput int) []string {
+	t.Helper()
+	var out []string
+	args := logOutputArgs{MaxOutput: maxOutput}
+	for {
+		page, err := args.limit(logs, defaultMaxOutput)
+		if err != nil {
+			t.Fatalf("limit: %v", err)
+		}
+		out = append(out, page)
+		m := cursorPattern.FindStringSubmatch(page)
+		if m == nil {
+			return out
+		}
+		if len(out) > 10000 {
+			t.Fatal("cursors do not end")
+		}
+		args.Cursor = m[1]
+	}
+}
+
+func TestLimitStaysWithinMaxOutput(t *testing.T) {
+	for _, tc := range []struct{ logs, lines, maxOutput int }{
+		{80, 500, 20000},
+		{5, 2000, 5000},
+		{300, 50, 10000},
+		{1, 5000, 1000},
+	} {
+		logs := testLogs(tc.logs, tc.lines)
+		for i, page := range pages(t, logs, tc.maxOutput) {
+			if len(page) > tc.maxOutput {
+				t.Errorf("%d logs of %d lines, page %d: got %d characters, limit %d", tc.logs, tc.lines, i, len(page), tc.maxOutput)
+			}
+		}
+	}
+}
+
+func TestLimitCursorsReturnEveryLineOnce(t *testing.T) {
+	for _, tc := range []struct{ logs, lines, maxOutput int }{
+		{80, 500, 20000},
+		{5, 2000, 5000},
+		{300, 50, 10000},
+	} {
+		logs := testLogs(tc.logs, tc.lines)
+		seen := map[string]int{}
+		for _, page := range pages(t, logs, tc.maxOutput) {
+			for _, line := range splitLines(page) {
+				if strings.HasPrefix(line, "log ") || strings.HasPrefix(line, "##[error]") {
+					seen[line]++
+				}
+			}
+		}
+		for _, line := range splitLines(logs) {
+			if isSectionHeader(line) {
+				continue
+			}
+			if seen[line] != 1 {
+				t.Errorf("%d logs of %d lines: %q returned %d times", tc.logs, tc.lines, line, seen[line])
+				break
+			}
+		}
+	}
+}
+
+func TestLimitKeepsErrorAfterManySections(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 120; i++ {
+		fmt.Fprintf(&b, "--- Log ID %d ---\n", i)
+		for j := 1; j <= 300; j++ {
+			fmt.Fprintf(&b, "log %d line %d\n", i, j)
+		}
+	}
+	b.WriteString("##[error]the build failed\n")
+	logs := b.String()
+
+	out, err := logOutputArgs{}.limit(logs, defaultMaxOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) > defaultMaxOutput {
+		t.Errorf("got %d characters, limit %d", len(out), defaultMaxOutput)
+	}
+	if !strings.Contains(out, "##[error]the build failed\n") {
+		t.Error("error line in the last section omitted")
+	}
+	for i := 1; i <= 120; i++ {
+		if !strings.Contains(out, fmt.Sprintf("--- Log ID %d ---\n", i)) {
+			t.Errorf("header of log %d omitted", i)
+		}
+	}
+}
+
+func TestLimitShortLogsUnchanged(t *testing.T) {
+	logs := testLogs(2, 10)
+	out, err := logOutputArgs{}.limit(logs, defaultMaxOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != logs {
+		t.Errorf("short logs were changed:\n%s", out)
+	}
+}
+
+func TestLimitInvalidCursor(t *testing.T) {
+	if _, err := (logOutputArgs{Cursor: "!!"}).limit(testLogs(1, 10), defaultMaxOutput); err == nil {
+		t.Error("invalid cursor accepted")
+	}
+}
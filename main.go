@@ -1,7 +1,7 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
@@ -10,6 +10,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -20,13 +23,24 @@ func main() {
 		// Usually silent ignore or log info is fine for optional .env
 	}
 
-	var port string
+	var port, transport string
+	var readOnly bool
 	defaultPort := os.Getenv("PORT")
 	if defaultPort == "" {
 		defaultPort = "8080"
 	}
+	defaultReadOnly := false
+	if v := os.Getenv("ADO_READ_ONLY"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid ADO_READ_ONLY %q: expected true or false", v)
+		}
+		defaultReadOnly = b
+	}
 
 	flag.StringVar(&port, "port", defaultPort, "Port to listen on")
+	flag.StringVar(&transport, "transport", "http", "Transport to serve: http (Streamable HTTP and legacy SSE) or stdio")
+	flag.BoolVar(&readOnly, "read-only", defaultReadOnly, "Refuse to register or run any tool not marked read-only")
 	flag.Parse()
 
 	adoURL := os.Getenv("ADO_URL")
@@ -38,271 +52,136 @@ func main() {
 		log.Fatal("ADO_URL and ADO_TOKEN environment variables are required")
 	}
 
-	client := azuredevops.NewClient(adoURL, adoOrg, adoProject, adoToken)
-	server := mcp.NewServer()
-
-	// Register list_builds
-	server.RegisterTool(mcp.Tool{
-		Name:        "list_builds",
-		Description: "List recent builds",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"top": map[string]interface{}{
-					"type": "integer",
-					"description": "Number of builds to retrieve (default 10)",
-				},
-				"project": map[string]interface{}{
-					"type": "string",
-					"description": "Project name (optional, overrides default)",
-				},
-			},
-		},
-	}, func(args map[string]interface{}) (*mcp.CallToolResult, error) {
-		top := 10
-		if t, ok := args["top"].(float64); ok {
-			top = int(t)
-		}
-		project, _ := args["project"].(string)
-		
-		builds, err := client.GetBuilds(project, top)
-		if err != nil {
-			return nil, err
+	pollInterval := 30 * time.Second
+	if v := os.Getenv("ADO_POLL_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid ADO_POLL_INTERVAL %q: expected a positive duration such as 30s", v)
 		}
-		
-		buildsJSON, _ := json.MarshalIndent(builds, "", "  ")
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: string(buildsJSON)}},
-		}, nil
-	})
+		pollInterval = d
+	}
 
-	// Register get_build
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_build",
-		Description: "Get build details",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"buildId": map[string]interface{}{
-					"type": "integer",
-					"description": "ID of the build",
-				},
-				"project": map[string]interface{}{
-					"type": "string",
-					"description": "Project name (optional, overrides default)",
-				},
-			},
-			"required": []string{"buildId"},
-		},
-	}, func(args map[string]interface{}) (*mcp.CallToolResult, error) {
-		buildIdFloat, ok := args["buildId"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("buildId is required and must be an integer")
-		}
-		buildId := int(buildIdFloat)
-		project, _ := args["project"].(string)
-		
-		build, err := client.GetBuild(project, buildId)
-		if err != nil {
-			return nil, err
+	maxOutput := defaultMaxOutput
+	if v := os.Getenv("ADO_MAX_OUTPUT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1000 {
+			log.Fatalf("Invalid ADO_MAX_OUTPUT %q: expected a number of characters of at least 1000", v)
 		}
-		
-		buildJSON, _ := json.MarshalIndent(build, "", "  ")
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: string(buildJSON)}},
-		}, nil
-	})
+		maxOutput = n
+	}
 
-	// Register get_build_logs
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_build_logs",
-		Description: "Get build logs",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"buildId": map[string]interface{}{
-					"type": "integer",
-					"description": "ID of the build",
-				},
-				"project": map[string]interface{}{
-					"type": "string",
-					"description": "Project name (optional, overrides default)",
-				},
-			},
-			"required": []string{"buildId"},
-		},
-	}, func(args map[string]interface{}) (*mcp.CallToolResult, error) {
-		buildIdFloat, ok := args["buildId"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("buildId is required and must be an integer")
+	client := azuredevops.NewClient(adoURL, adoOrg, adoProject, adoToken)
+	if v := os.Getenv("ADO_RETRY_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid ADO_RETRY_MAX_ATTEMPTS %q: expected a positive number", v)
 		}
-		buildId := int(buildIdFloat)
-		project, _ := args["project"].(string)
-		
-		logs, err := client.GetBuildLogs(project, buildId)
-		if err != nil {
-			return nil, err
+		client.Retry.MaxAttempts = n
+	}
+	if v := os.Getenv("ADO_RETRY_MAX_ELAPSED"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid ADO_RETRY_MAX_ELAPSED %q: expected a duration such as 2m, or 0 for no limit", v)
 		}
-		
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: logs}},
-		}, nil
-	})
-
-	// Register list_releases
-	server.RegisterTool(mcp.Tool{
-		Name:        "list_releases",
-		Description: "List recent releases",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"top": map[string]interface{}{
-					"type": "integer",
-					"description": "Number of releases to retrieve (default 10)",
-				},
-				"project": map[string]interface{}{
-					"type": "string",
-					"description": "Project name (optional, overrides default)",
-				},
-			},
-		},
-	}, func(args map[string]interface{}) (*mcp.CallToolResult, error) {
-		top := 10
-		if t, ok := args["top"].(float64); ok {
-			top = int(t)
+		client.Retry.MaxElapsed = d
+	}
+	if v := os.Getenv("ADO_LOG_CONCURRENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid ADO_LOG_CONCURRENCY %q: expected a positive number", v)
 		}
-		project, _ := args["project"].(string)
-		
-		releases, err := client.GetReleases(project, top)
-		if err != nil {
-			return nil, err
+		client.Concurrency = n
+	}
+	if v := os.Getenv("ADO_CACHE_SIZE"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid ADO_CACHE_SIZE %q: expected a number of megabytes, or 0 to disable caching", v)
 		}
-		
-		releasesJSON, _ := json.MarshalIndent(releases, "", "  ")
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: string(releasesJSON)}},
-		}, nil
-	})
-
-	// Register get_release
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_release",
-		Description: "Get release details",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"releaseId": map[string]interface{}{
-					"type": "integer",
-					"description": "ID of the release",
-				},
-				"project": map[string]interface{}{
-					"type": "string",
-					"description": "Project name (optional, overrides default)",
-				},
-			},
-			"required": []string{"releaseId"},
-		},
-	}, func(args map[string]interface{}) (*mcp.CallToolResult, error) {
-		releaseIdFloat, ok := args["releaseId"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("releaseId is required and must be an integer")
+		client.Cache = nil
+		if n > 0 {
+			client.Cache = azuredevops.NewMemoryCache(n << 20)
 		}
-		releaseId := int(releaseIdFloat)
-		project, _ := args["project"].(string)
-		
-		release, err := client.GetRelease(project, releaseId)
+	}
+	if dir := os.Getenv("ADO_CACHE_DIR"); dir != "" {
+		disk, err := azuredevops.NewDiskCache(dir)
 		if err != nil {
-			return nil, err
+			log.Fatalf("Invalid ADO_CACHE_DIR: %v", err)
 		}
-		
-		releaseJSON, _ := json.MarshalIndent(release, "", "  ")
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: string(releaseJSON)}},
-		}, nil
-	})
-
-	// Register get_release_logs
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_release_logs",
-		Description: "Get release logs",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"releaseId": map[string]interface{}{
-					"type": "integer",
-					"description": "ID of the release",
-				},
-				"project": map[string]interface{}{
-					"type": "string",
-					"description": "Project name (optional, overrides default)",
-				},
-			},
-			"required": []string{"releaseId"},
-		},
-	}, func(args map[string]interface{}) (*mcp.CallToolResult, error) {
-		releaseIdFloat, ok := args["releaseId"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("releaseId is required and must be an integer")
+		if client.Cache != nil {
+			client.Cache = azuredevops.NewTieredCache(client.Cache, disk)
+		} else {
+			client.Cache = disk
 		}
-		releaseId := int(releaseIdFloat)
-		project, _ := args["project"].(string)
-		
-		logs, err := client.GetReleaseLogs(project, releaseId)
-		if err != nil {
-			return nil, err
+	}
+	if v := os.Getenv("ADO_CACHE_LIST_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid ADO_CACHE_LIST_TTL %q: expected a duration such as 30s, or 0 to disable caching of lists", v)
 		}
-		
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: logs}},
-		}, nil
-	})
-
-	// Register get_logs_from_url
-	server.RegisterTool(mcp.Tool{
-		Name:        "get_logs_from_url",
-		Description: "Get logs from a build or release URL",
-		InputSchema: map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"url": map[string]interface{}{
-					"type": "string",
-					"description": "The full URL of the build or release",
-				},
-			},
-			"required": []string{"url"},
-		},
-	}, func(args map[string]interface{}) (*mcp.CallToolResult, error) {
-		urlStr, ok := args["url"].(string)
-		if !ok {
-			return nil, fmt.Errorf("url is required and must be a string")
+		client.ListTTL = d
+	}
+	if path := os.Getenv("ADO_REDACT_FILE"); path != "" {
+		if err := loadRedactPatterns(client.Redactor, path); err != nil {
+			log.Fatalf("Invalid ADO_REDACT_FILE: %v", err)
 		}
+	}
 
-		parsed, err := azuredevops.ParseURL(urlStr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse URL: %v", err)
+	server := mcp.NewServer()
+	server.ReadOnly = readOnly
+	if v := os.Getenv("ADO_SESSION_IDLE_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid ADO_SESSION_IDLE_TIMEOUT %q: expected a positive duration such as 30m", v)
 		}
-
-		var logs string
-		switch parsed.Type {
-		case azuredevops.ResourceBuild:
-			logs, err = client.GetBuildLogs(parsed.Project, parsed.ID)
-		case azuredevops.ResourceRelease:
-			logs, err = client.GetReleaseLogs(parsed.Project, parsed.ID)
-		default:
-			return nil, fmt.Errorf("unknown resource type")
+		server.SessionIdleTimeout = d
+	}
+	if v := os.Getenv("ADO_ALLOWED_ORIGINS"); v != "" {
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				server.AllowedOrigins = append(server.AllowedOrigins, origin)
+			}
 		}
+	}
+	server.TextFilter = client.Redactor.Redact
+
+	registerTools(server, client, maxOutput)
+	registerResources(server, client, maxOutput)
+	registerPrompts(server, client, maxOutput)
+	watcher := registerSubscriptions(server, client, pollInterval)
+	go watcher.Run(context.Background())
+
+	switch transport {
+	case "stdio":
+		// stdout carries the protocol, so logs stay on stderr
+		log.Printf("Starting MCP server on stdio...")
+		if err := server.ServeStdio(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	case "http", "sse": // sse is the former name of http
+		log.Printf("Starting MCP server on port %s...", port)
+		if err := http.ListenAndServe(":"+port, server); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("Unknown transport %q (expected http or stdio)", transport)
+	}
+}
 
-		if err != nil {
-			return nil, err
+// loadRedactPatterns adds the regular expressions in a file, one per line,
+// to the redactor. Blank lines and lines starting with # are ignored.
+func loadRedactPatterns(redactor *azuredevops.Redactor, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := redactor.AddPattern(line); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, i+1, err)
 		}
-
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{{Type: "text", Text: logs}},
-		}, nil
-	})
-
-	log.Printf("Starting MCP server on port %s...", port)
-	if err := http.ListenAndServe(":"+port, server); err != nil {
-		log.Fatal(err)
 	}
+	return nil
 }
@@ -0,0 +1,69 @@
+package mcp
+
+import "encoding/json"
+
+// filterToolResult applies the server's TextFilter to a tool result. The
+// structured content is filtered through its JSON form; if the filter
+// leaves invalid JSON behind, the structured content is dropped rather than
+// sent unfiltered.
+func (s *Server) filterToolResult(result *CallToolResult) *CallToolResult {
+	if s.TextFilter == nil || result == nil {
+		return result
+	}
+
+	filtered := *result
+	filtered.Content = s.filterContent(result.Content)
+
+	if result.StructuredContent != nil {
+		filtered.StructuredContent = nil
+		if data, err := json.Marshal(result.StructuredContent); err == nil {
+			var structured interface{}
+			if json.Unmarshal([]byte(s.TextFilter(string(data))), &structured) == nil {
+				filtered.StructuredContent = structured
+			}
+		}
+	}
+	return &filtered
+}
+
+func (s *Server) filterPromptResult(result *GetPromptResult) *GetPromptResult {
+	if s.TextFilter == nil || result == nil {
+		return result
+	}
+
+	filtered := *result
+	filtered.Messages = make([]PromptMessage, len(result.Messages))
+	for i, msg := range result.Messages {
+		msg.Content = s.filterContent([]Content{msg.Content})[0]
+		filtered.Messages[i] = msg
+	}
+	return &filtered
+}
+
+func (s *Server) filterResourceResult(result *ReadResourceResult) *ReadResourceResult {
+	if s.TextFilter == nil || result == nil {
+		return result
+	}
+
+	filtered := *result
+	filtered.Contents = make([]ResourceContents, len(result.Contents))
+	for i, contents := range result.Contents {
+		contents.Text = s.TextFilter(contents.Text)
+		filtered.Contents[i] = contents
+	}
+	return &filtered
+}
+
+func (s *Server) filterContent(content []Content) []Content {
+	filtered := make([]Content, len(content))
+	for i, c := range content {
+		c.Text = s.TextFilter(c.Text)
+		if c.Resource != nil {
+			resource := *c.Resource
+			resource.Text = s.TextFilter(resource.Text)
+			c.Resource = &resource
+		}
+		filtered[i] = c
+	}
+	return filtered
+}
@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+)
+
+// defaultPageSize is used by list methods when Server.PageSize is not set.
+const defaultPageSize = 50
+
+// paginate sorts names and returns the page that follows cursor, along with
+// the cursor of the next page ("" on the last page). Cursors hold the last
+// name of the previous page, so pages stay stable when items are added.
+func (s *Server) paginate(names []string, params json.RawMessage) ([]string, string, *JSONRPCError) {
+	var req PaginatedRequest
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, "", &JSONRPCError{Code: -32602, Message: "Invalid params"}
+		}
+	}
+
+	sort.Strings(names)
+
+	start := 0
+	if req.Cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+		if err != nil {
+			return nil, "", &JSONRPCError{Code: -32602, Message: "Invalid cursor"}
+		}
+		start = sort.SearchStrings(names, string(after))
+		if start < len(names) && names[start] == string(after) {
+			start++
+		}
+	}
+
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	end := start + pageSize
+	if end >= len(names) {
+		return names[start:], "", nil
+	}
+	return names[start:end], base64.RawURLEncoding.EncodeToString([]byte(names[end-1])), nil
+}
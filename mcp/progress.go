@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type notifierKey struct{}
+
+type progressKey struct{}
+
+type progressReporter struct {
+	token interface{}
+	send  func(msg string)
+}
+
+// withNotifier routes notifications emitted while handling a request to send
+// instead of the session's standalone stream, e.g. onto the SSE stream that
+// answers a Streamable HTTP POST.
+func withNotifier(ctx context.Context, send func(msg string)) context.Context {
+	return context.WithValue(ctx, notifierKey{}, send)
+}
+
+// withProgressToken makes ReportProgress deliver notifications for token.
+func withProgressToken(ctx context.Context, sess *session, token interface{}) context.Context {
+	send, ok := ctx.Value(notifierKey{}).(func(msg string))
+	if !ok {
+		send = sess.send
+	}
+	return context.WithValue(ctx, progressKey{}, &progressReporter{token: token, send: send})
+}
+
+// ReportProgress sends notifications/progress for the request being handled
+// with ctx. It does nothing unless the client supplied a progress token.
+// total may be 0 when unknown; progress must increase with every call.
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	reporter, ok := ctx.Value(progressKey{}).(*progressReporter)
+	if !ok {
+		return
+	}
+
+	params := map[string]interface{}{
+		"progressToken": reporter.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+
+	msg, _ := json.Marshal(JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/progress",
+		Params:  params,
+	})
+	reporter.send(string(msg))
+}
+
+// progressToken extracts _meta.progressToken from request params, if any.
+func progressToken(params json.RawMessage) interface{} {
+	if len(params) == 0 {
+		return nil
+	}
+
+	var withMeta struct {
+		Meta struct {
+			ProgressToken interface{} `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &withMeta); err != nil {
+		return nil
+	}
+	return withMeta.Meta.ProgressToken
+}
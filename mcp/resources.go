@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// ResourceHandler reads the resource at uri. vars holds the values matched
+// by the template the handler was registered with.
+type ResourceHandler func(ctx context.Context, uri string, vars map[string]string) (*ReadResourceResult, error)
+
+// ResourceLister returns concrete resources to advertise in resources/list.
+type ResourceLister func(ctx context.Context) ([]Resource, error)
+
+// ErrResourceNotFound may be returned by a ResourceHandler when the URI
+// matches its template but does not name an existing resource.
+var ErrResourceNotFound = errors.New("resource not found")
+
+type registeredTemplate struct {
+	template ResourceTemplate
+	matcher  *uriTemplate
+	handler  ResourceHandler
+}
+
+// RegisterResourceTemplate makes every URI matching template.URITemplate
+// readable through handler. It panics on a malformed template, like
+// regexp.MustCompile, since templates are fixed at startup.
+func (s *Server) RegisterResourceTemplate(template ResourceTemplate, handler ResourceHandler) {
+	matcher, err := compileURITemplate(template.URITemplate)
+	if err != nil {
+		panic(err)
+	}
+	s.templates = append(s.templates, registeredTemplate{
+		template: template,
+		matcher:  matcher,
+		handler:  handler,
+	})
+}
+
+// RegisterResourceLister adds a source of concrete resources for resources/list.
+func (s *Server) RegisterResourceLister(lister ResourceLister) {
+	s.resourceListers = append(s.resourceListers, lister)
+}
+
+func (s *Server) hasResources() bool {
+	return len(s.templates) > 0 || len(s.resourceListers) > 0
+}
+
+func (s *Server) listResources(ctx context.Context) []Resource {
+	resources := []Resource{}
+	for _, lister := range s.resourceListers {
+		listed, err := lister(ctx)
+		if err != nil {
+			// One failing source should not hide the others
+			log.Printf("Failed to list resources: %v", err)
+			continue
+		}
+		resources = append(resources, listed...)
+	}
+	return resources
+}
+
+func (s *Server) listResourceTemplates() []ResourceTemplate {
+	templates := make([]ResourceTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		templates = append(templates, t.template)
+	}
+	return templates
+}
+
+func (s *Server) readResource(ctx context.Context, uri string) (*ReadResourceResult, *JSONRPCError) {
+	for _, t := range s.templates {
+		vars, ok := t.matcher.Match(uri)
+		if !ok {
+			continue
+		}
+
+		result, err := t.handler(ctx, uri, vars)
+		if errors.Is(err, ErrResourceNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, &JSONRPCError{Code: -32603, Message: err.Error()}
+		}
+		return result, nil
+	}
+
+	return nil, &JSONRPCError{
+		Code:    -32002,
+		Message: "Resource not found",
+		Data:    map[string]interface{}{"uri": uri},
+	}
+}
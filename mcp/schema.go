@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema used to describe tool inputs and outputs.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // bool or *Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor derives a JSON Schema from a Go type. Struct fields are named
+// after their json tag and are required unless tagged omitempty. Two more
+// tags refine a field:
+//
+//	description:"Number of builds to retrieve"
+//	jsonschema:"minimum=1,maximum=5000,enum=a|b"
+func SchemaFor(t reflect.Type) *Schema {
+	return schemaFor(t, map[reflect.Type]bool{})
+}
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes []byte as base64
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive types are described only down to their first repetition
+			return &Schema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addStructFields(schema, t, visiting)
+		return schema
+	default:
+		// interface{} and friends accept any value
+		return &Schema{}
+	}
+}
+
+func addStructFields(schema *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, omitempty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			// Embedded structs are flattened, as encoding/json does
+			addStructFields(schema, indirect(field.Type), visiting)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := schemaFor(field.Type, visiting)
+		prop.Description = field.Tag.Get("description")
+		applySchemaTag(prop, field.Tag.Get("jsonschema"))
+
+		schema.Properties[name] = prop
+		if !omitempty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func jsonFieldName(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return parts[0], omitempty, false
+}
+
+// applySchemaTag applies comma separated key=value constraints from a
+// jsonschema struct tag.
+func applySchemaTag(schema *Schema, tag string) {
+	if tag == "" {
+		return
+	}
+
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "minimum":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Minimum = &f
+			}
+		case "maximum":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Maximum = &f
+			}
+		case "enum":
+			// On arrays, the allowed values constrain the items
+			target := schema
+			if schema.Type == "array" && schema.Items != nil {
+				target = schema.Items
+			}
+			for _, v := range strings.Split(value, "|") {
+				target.Enum = append(target.Enum, v)
+			}
+		case "format":
+			schema.Format = value
+		}
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -1,37 +1,114 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
-
-	"github.com/google/uuid"
+	"time"
 )
 
-type ToolHandler func(arguments map[string]interface{}) (*CallToolResult, error)
+// ToolHandler runs a tool call. ctx is cancelled when the client cancels the
+// request or its session closes.
+type ToolHandler func(ctx context.Context, arguments map[string]interface{}) (*CallToolResult, error)
+
+type PromptHandler func(ctx context.Context, arguments map[string]string) (*GetPromptResult, error)
 
 type Server struct {
+	// ReadOnly refuses to register or call any tool not annotated as
+	// read-only. It must be set before tools are registered.
+	ReadOnly bool
+
+	// PageSize is the number of items per page of tools/list and
+	// prompts/list (default 50).
+	PageSize int
+
+	// TextFilter, when set, rewrites the text of every tool result, prompt
+	// message and resource before it is sent, e.g. to redact secrets.
+	TextFilter func(string) string
+
+	// SessionIdleTimeout closes Streamable HTTP sessions that have had no
+	// request and no open stream for that long (default 30 minutes), as
+	// clients may go away without terminating their session.
+	SessionIdleTimeout time.Duration
+
+	// AllowedOrigins are the browser origins, besides localhost, allowed to
+	// connect, e.g. "https://app.example.com", or "*" for any. Requests
+	// from other origins are rejected to prevent DNS rebinding attacks.
+	AllowedOrigins []string
+
 	Tools map[string]Tool
 	Handlers map[string]ToolHandler
-	sessions sync.Map // map[string]chan string
+	Prompts map[string]Prompt
+	PromptHandlers map[string]PromptHandler
+	sessions sync.Map // map[string]*session
+
+	inputSchemas map[string]*Schema // normalized Tool.InputSchema, by tool name
+
+	templates       []registeredTemplate
+	resourceListers []ResourceLister
+
+	reaper sync.Once // starts closing idle sessions
+
+	subscriptionHandler SubscriptionHandler
+	subscriptionsMu     sync.Mutex
+	subscribers         map[string]map[string]*session // uri -> session ID -> session
+	handlerCalls        map[string]chan struct{}       // uri -> closed when its handler call returns
 }
 
 func NewServer() *Server {
 	return &Server{
 		Tools:    make(map[string]Tool),
 		Handlers: make(map[string]ToolHandler),
+		Prompts:  make(map[string]Prompt),
+		PromptHandlers: make(map[string]PromptHandler),
+
+		inputSchemas: make(map[string]*Schema),
+		subscribers:  make(map[string]map[string]*session),
+		handlerCalls: make(map[string]chan struct{}),
 	}
 }
 
 func (s *Server) RegisterTool(tool Tool, handler ToolHandler) {
+	if s.ReadOnly && !tool.IsReadOnly() {
+		log.Printf("Read-only mode: not registering tool %s", tool.Name)
+		return
+	}
+
 	s.Tools[tool.Name] = tool
 	s.Handlers[tool.Name] = handler
+
+	if tool.InputSchema == nil {
+		return
+	}
+	schema, err := toSchema(tool.InputSchema)
+	if err != nil {
+		log.Printf("Tool %s: input schema not supported for validation: %v", tool.Name, err)
+		return
+	}
+	s.inputSchemas[tool.Name] = schema
+}
+
+func (s *Server) RegisterPrompt(prompt Prompt, handler PromptHandler) {
+	s.Prompts[prompt.Name] = prompt
+	s.PromptHandlers[prompt.Name] = handler
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.allowedOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
 	// Simple router
+	if r.URL.Path == "/mcp" {
+		s.handleStreamable(w, r)
+		return
+	}
 	if r.URL.Path == "/sse" {
 		s.handleSSE(w, r)
 		return
@@ -43,6 +120,29 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	http.NotFound(w, r)
 }
 
+// allowedOrigin reports whether a request with the given Origin header may
+// be served. Requests without one do not come from a browser.
+func (s *Server) allowedOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	default:
+		return false
+	}
+}
+
 func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	// Set headers for SSE
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -56,10 +156,10 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sessionID := uuid.New().String()
-	msgChan := make(chan string, 10)
-	s.sessions.Store(sessionID, msgChan)
-	defer s.sessions.Delete(sessionID)
+	sess := s.newSession()
+	sessionID := sess.id
+	msgChan := sess.messages
+	defer s.closeSession(sessionID)
 
 	// Send endpoint event
 	endpoint := fmt.Sprintf("/message?sessionId=%s", sessionID)
@@ -94,12 +194,12 @@ func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	val, ok := s.sessions.Load(sessionID)
+	sess, ok := s.lookupSession(sessionID)
 	if !ok {
 		http.Error(w, "Session not found", http.StatusNotFound)
 		return
 	}
-	msgChan := val.(chan string)
+	msgChan := sess.messages
 
 	var req JSONRPCRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -111,23 +211,67 @@ func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 	
 	// Process request asynchronously
-	go s.processRequest(req, msgChan)
+	go s.processRequest(sess.ctx, sess, req, msgChan)
 }
 
-func (s *Server) processRequest(req JSONRPCRequest, msgChan chan string) {
+func (s *Server) processRequest(ctx context.Context, sess *session, req JSONRPCRequest, msgChan chan string) {
+	response := s.handleRequest(ctx, sess, req)
+	if response == nil {
+		return
+	}
+
+	respBytes, _ := json.Marshal(response)
+	select {
+	case msgChan <- string(respBytes):
+	case <-sess.done:
+		// Nobody is left to read the response
+	}
+}
+
+// handleRequest dispatches a single JSON-RPC message and returns the response
+// to send back, or nil when the message is a notification.
+func (s *Server) handleRequest(ctx context.Context, sess *session, req JSONRPCRequest) *JSONRPCResponse {
 	var response JSONRPCResponse
 	response.JSONRPC = "2.0"
 	response.ID = req.ID
 
+	if !req.isNotification() {
+		// Make the request cancellable through notifications/cancelled
+		var cancel context.CancelFunc
+		ctx, cancel = sess.startRequest(ctx, req.ID)
+		defer sess.finishRequest(req.ID, cancel)
+
+		if token := progressToken(req.Params); token != nil {
+			ctx = withProgressToken(ctx, sess, token)
+		}
+	}
+
 	switch req.Method {
 	case "tools/list":
-		tools := make([]Tool, 0, len(s.Tools))
-		for _, t := range s.Tools {
-			tools = append(tools, t)
+		names := make([]string, 0, len(s.Tools))
+		for name, t := range s.Tools {
+			if s.ReadOnly && !t.IsReadOnly() {
+				continue
+			}
+			names = append(names, name)
 		}
-		response.Result = map[string]interface{}{
+		page, nextCursor, rpcErr := s.paginate(names, req.Params)
+		if rpcErr != nil {
+			response.Error = rpcErr
+			break
+		}
+
+		tools := make([]Tool, 0, len(page))
+		for _, name := range page {
+			tools = append(tools, s.Tools[name])
+		}
+		result := map[string]interface{}{
 			"tools": tools,
 		}
+		if nextCursor != "" {
+			result["nextCursor"] = nextCursor
+		}
+		response.Result = result
 	case "tools/call":
 		var callReq CallToolRequest
 		// We need to re-marshal params to decode into CallToolRequest because Params is RawMessage
@@ -142,32 +286,160 @@ func (s *Server) processRequest(req JSONRPCRequest, msgChan chan string) {
 			response.Error = &JSONRPCError{Code: -32601, Message: "Method not found"}
 			break
 		}
+		if s.ReadOnly && !s.Tools[callReq.Name].IsReadOnly() {
+			// Guards against tools added to the maps directly
+			response.Error = &JSONRPCError{Code: -32601, Message: "Tool not allowed in read-only mode: " + callReq.Name}
+			break
+		}
+
+		// Reject arguments that do not match the schema before they reach the handler
+		if schema := s.inputSchemas[callReq.Name]; schema != nil {
+			if err := validateArguments(schema, callReq.Arguments); err != nil {
+				response.Error = invalidParamsError(err)
+				break
+			}
+		}
 
-		result, err := handler(callReq.Arguments)
+		result, err := handler(ctx, callReq.Arguments)
 		if err != nil {
-			response.Result = CallToolResult{
+			result = &CallToolResult{
 				Content: []Content{{Type: "text", Text: err.Error()}},
 				IsError: true,
 			}
+		}
+		response.Result = s.filterToolResult(result)
+	case "prompts/list":
+		names := make([]string, 0, len(s.Prompts))
+		for name := range s.Prompts {
+			names = append(names, name)
+		}
+		page, nextCursor, rpcErr := s.paginate(names, req.Params)
+		if rpcErr != nil {
+			response.Error = rpcErr
+			break
+		}
+
+		prompts := make([]Prompt, 0, len(page))
+		for _, name := range page {
+			prompts = append(prompts, s.Prompts[name])
+		}
+		result := map[string]interface{}{
+			"prompts": prompts,
+		}
+		if nextCursor != "" {
+			result["nextCursor"] = nextCursor
+		}
+		response.Result = result
+	case "prompts/get":
+		var getReq GetPromptRequest
+		if err := json.Unmarshal(req.Params, &getReq); err != nil {
+			response.Error = &JSONRPCError{Code: -32602, Message: "Invalid params"}
+			break
+		}
+
+		prompt, ok := s.Prompts[getReq.Name]
+		if !ok {
+			response.Error = &JSONRPCError{Code: -32602, Message: "Unknown prompt: " + getReq.Name}
+			break
+		}
+		if missing := missingPromptArgument(prompt, getReq.Arguments); missing != "" {
+			response.Error = &JSONRPCError{Code: -32602, Message: "Missing required argument: " + missing}
+			break
+		}
+
+		result, err := s.PromptHandlers[getReq.Name](ctx, getReq.Arguments)
+		if err != nil {
+			response.Error = &JSONRPCError{Code: -32603, Message: err.Error()}
+			break
+		}
+		response.Result = s.filterPromptResult(result)
+	case "resources/list":
+		response.Result = map[string]interface{}{
+			"resources": s.listResources(ctx),
+		}
+	case "resources/templates/list":
+		response.Result = map[string]interface{}{
+			"resourceTemplates": s.listResourceTemplates(),
+		}
+	case "resources/read":
+		var readReq ReadResourceRequest
+		if err := json.Unmarshal(req.Params, &readReq); err != nil || readReq.URI == "" {
+			response.Error = &JSONRPCError{Code: -32602, Message: "Invalid params"}
+			break
+		}
+
+		result, rpcErr := s.readResource(ctx, readReq.URI)
+		if rpcErr != nil {
+			response.Error = rpcErr
+			break
+		}
+		response.Result = s.filterResourceResult(result)
+	case "resources/subscribe", "resources/unsubscribe":
+		var subReq SubscribeRequest
+		if err := json.Unmarshal(req.Params, &subReq); err != nil || subReq.URI == "" {
+			response.Error = &JSONRPCError{Code: -32602, Message: "Invalid params"}
+			break
+		}
+		if s.subscriptionHandler == nil {
+			response.Error = &JSONRPCError{Code: -32601, Message: "Method not found: " + req.Method}
+			break
+		}
+
+		var err error
+		if req.Method == "resources/subscribe" {
+			err = s.subscribe(ctx, sess, subReq.URI)
 		} else {
-			response.Result = result
+			err = s.unsubscribe(ctx, sess, subReq.URI)
+		}
+		if err != nil {
+			response.Error = &JSONRPCError{Code: -32602, Message: err.Error()}
+			break
 		}
+		response.Result = map[string]interface{}{}
 	case "initialize":
 		// Handle initialize
+		var initReq InitializeRequest
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &initReq); err != nil {
+				response.Error = &JSONRPCError{Code: -32602, Message: "Invalid params"}
+				break
+			}
+		}
+		capabilities := map[string]interface{}{
+			"tools": map[string]interface{}{},
+		}
+		if len(s.Prompts) > 0 {
+			capabilities["prompts"] = map[string]interface{}{}
+		}
+		if s.hasResources() {
+			capabilities["resources"] = map[string]interface{}{
+				"subscribe": s.subscriptionHandler != nil,
+			}
+		}
 		response.Result = map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities": map[string]interface{}{
-				"tools": map[string]interface{}{},
-			},
+			"protocolVersion": negotiateProtocolVersion(initReq.ProtocolVersion),
+			"capabilities": capabilities,
 			"serverInfo": map[string]interface{}{
 				"name": "adomcp",
 				"version": "1.0.0",
 			},
 		}
+	case "ping":
+		response.Result = map[string]interface{}{}
 	case "notifications/initialized":
 		// No response needed for notifications
-		return
+		return nil
+	case "notifications/cancelled":
+		var cancelled CancelledNotification
+		if err := json.Unmarshal(req.Params, &cancelled); err == nil {
+			sess.cancelRequest(cancelled.RequestID)
+		}
+		return nil
 	default:
+		if req.isNotification() {
+			// Unknown notifications are ignored, they never get a response
+			return nil
+		}
 		// Ignore other methods or return error
 		// For MCP, we should probably return MethodNotFound if we don't handle it
 		// But for ping/etc we might want to be silent or generic.
@@ -175,6 +447,43 @@ func (s *Server) processRequest(req JSONRPCRequest, msgChan chan string) {
 		response.Error = &JSONRPCError{Code: -32601, Message: "Method not found: " + req.Method}
 	}
 
-	respBytes, _ := json.Marshal(response)
-	msgChan <- string(respBytes)
+	return &response
+}
+
+// invalidParamsError reports failed argument validation as -32602, with the
+// offending field in the error data when known.
+func invalidParamsError(err error) *JSONRPCError {
+	rpcErr := &JSONRPCError{Code: -32602, Message: "Invalid params: " + err.Error()}
+	if argErr, ok := err.(*ArgumentError); ok {
+		rpcErr.Data = map[string]interface{}{
+			"field":  argErr.Field,
+			"reason": argErr.Reason,
+		}
+	}
+	return rpcErr
+}
+
+// missingPromptArgument returns the name of the first required argument of
+// prompt that is absent from arguments, or "" when all are present.
+func missingPromptArgument(prompt Prompt, arguments map[string]string) string {
+	for _, arg := range prompt.Arguments {
+		if arg.Required && arguments[arg.Name] == "" {
+			return arg.Name
+		}
+	}
+	return ""
+}
+
+// supportedProtocolVersions lists the protocol revisions the server speaks, newest first.
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// negotiateProtocolVersion echoes the client's requested version when it is
+// supported and otherwise proposes the latest version the server knows.
+func negotiateProtocolVersion(requested string) string {
+	for _, v := range supportedProtocolVersions {
+		if v == requested {
+			return v
+		}
+	}
+	return supportedProtocolVersions[0]
 }
@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// session is the per-client state shared by every transport. messages
+// carries server-to-client messages for transports with a long-lived stream.
+// ctx is cancelled when the session closes, stopping all of its requests.
+type session struct {
+	id       string
+	messages chan string
+	done     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu            sync.Mutex
+	subscriptions map[string]bool
+	expires       bool                          // closed once idle, see Server.SessionIdleTimeout
+	users         int                           // requests and streams using the session
+	lastUsed      time.Time                     // when the session was last released by a user
+	inFlight      map[string]context.CancelFunc // request ID -> cancel
+}
+
+func (s *Server) newSession() *session {
+	ctx, cancel := context.WithCancel(context.Background())
+	sess := &session{
+		id:            uuid.New().String(),
+		messages:      make(chan string, 10),
+		done:          make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+		subscriptions: make(map[string]bool),
+		inFlight:      make(map[string]context.CancelFunc),
+		lastUsed:      time.Now(),
+	}
+	s.sessions.Store(sess.id, sess)
+	return sess
+}
+
+func (s *Server) lookupSession(id string) (*session, bool) {
+	val, ok := s.sessions.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return val.(*session), true
+}
+
+// closeSession removes the session and releases anything waiting on it.
+// It reports whether the session existed.
+func (s *Server) closeSession(id string) bool {
+	val, ok := s.sessions.LoadAndDelete(id)
+	if !ok {
+		return false
+	}
+	sess := val.(*session)
+	close(sess.done)
+	sess.cancel()
+
+	sess.mu.Lock()
+	uris := make([]string, 0, len(sess.subscriptions))
+	for uri := range sess.subscriptions {
+		uris = append(uris, uri)
+	}
+	sess.mu.Unlock()
+	for _, uri := range uris {
+		s.unsubscribe(context.Background(), sess, uri)
+	}
+	return true
+}
+
+// notify queues a server-initiated notification for the session. It never
+// blocks: the message is dropped when nobody is draining the session.
+func (sess *session) notify(method string, params interface{}) {
+	msg, _ := json.Marshal(JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+	sess.send(string(msg))
+}
+
+// send queues an already encoded message without blocking, like notify.
+func (sess *session) send(msg string) {
+	select {
+	case <-sess.done:
+	case sess.messages <- msg:
+	default:
+	}
+}
+
+// use marks the session as in use until the returned function is called.
+func (sess *session) use() func() {
+	sess.mu.Lock()
+	sess.users++
+	sess.mu.Unlock()
+	return func() {
+		sess.mu.Lock()
+		sess.users--
+		sess.lastUsed = time.Now()
+		sess.mu.Unlock()
+	}
+}
+
+// expireWhenIdle lets the session be closed once idle.
+func (sess *session) expireWhenIdle() {
+	sess.mu.Lock()
+	sess.expires = true
+	sess.mu.Unlock()
+}
+
+// idle returns how long an expiring session has not been in use, and 0 for
+// sessions that do not expire.
+func (sess *session) idle() time.Duration {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if !sess.expires || sess.users > 0 {
+		return 0
+	}
+	return time.Since(sess.lastUsed)
+}
+
+// requestContext derives a context for work done on behalf of an HTTP
+// request that also ends when the session closes.
+func (sess *session) requestContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(sess.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// startRequest registers an in-flight request so that it can be cancelled
+// by ID. finishRequest must be called once the request is done.
+func (sess *session) startRequest(parent context.Context, id interface{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sess.mu.Lock()
+	sess.inFlight[requestKey(id)] = cancel
+	sess.mu.Unlock()
+	return ctx, cancel
+}
+
+func (sess *session) finishRequest(id interface{}, cancel context.CancelFunc) {
+	sess.mu.Lock()
+	delete(sess.inFlight, requestKey(id))
+	sess.mu.Unlock()
+	cancel()
+}
+
+// cancelRequest stops an in-flight request; unknown or finished IDs are ignored.
+func (sess *session) cancelRequest(id interface{}) {
+	sess.mu.Lock()
+	cancel, ok := sess.inFlight[requestKey(id)]
+	sess.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// requestKey normalizes a JSON-RPC ID so that 1 and "1" stay distinct.
+func requestKey(id interface{}) string {
+	return fmt.Sprintf("%#v", id)
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxStdioMessageSize bounds a single newline-delimited JSON-RPC message read from stdin.
+const maxStdioMessageSize = 10 * 1024 * 1024
+
+// stdioShutdownGrace is how long requests in flight may take to finish once
+// stdin is closed.
+const stdioShutdownGrace = 30 * time.Second
+
+// ServeStdio runs the server over the stdio transport: newline-delimited
+// JSON-RPC messages are read from in and responses are written to out, one
+// per line. It returns once in is exhausted and the requests in flight have
+// finished; those still running after stdioShutdownGrace are cancelled.
+func (s *Server) ServeStdio(in io.Reader, out io.Writer) error {
+	// The whole stdio stream is a single session
+	sess := s.newSession()
+	msgChan := sess.messages
+
+	// Single writer so concurrent responses never interleave on out
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for {
+			select {
+			case msg := <-msgChan:
+				fmt.Fprintf(out, "%s\n", msg)
+			case <-sess.done:
+				// Flush whatever was queued before the session closed
+				for {
+					select {
+					case msg := <-msgChan:
+						fmt.Fprintf(out, "%s\n", msg)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStdioMessageSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var req JSONRPCRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			respBytes, _ := json.Marshal(JSONRPCResponse{
+				JSONRPC: "2.0",
+				Error:   &JSONRPCError{Code: -32700, Message: "Parse error"},
+				ID:      nullID,
+			})
+			msgChan <- string(respBytes)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.processRequest(sess.ctx, sess, req, msgChan)
+		}()
+	}
+
+	// Clients often write their requests and close stdin right away, so
+	// let the requests in flight finish, for a while
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(stdioShutdownGrace):
+	}
+	sess.cancel()
+	wg.Wait()
+	s.closeSession(sess.id)
+	<-writerDone
+
+	return scanner.Err()
+}
@@ -0,0 +1,291 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sessionHeader carries the session ID for the Streamable HTTP transport.
+const sessionHeader = "Mcp-Session-Id"
+
+// handleStreamable implements the Streamable HTTP transport on a single
+// endpoint: POST for client messages, GET for a server-initiated stream and
+// DELETE to terminate the session.
+func (s *Server) handleStreamable(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handleStreamablePost(w, r)
+	case http.MethodGet:
+		s.handleStreamableGet(w, r)
+	case http.MethodDelete:
+		s.handleStreamableDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleStreamablePost(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSONRPCError(w, http.StatusBadRequest, -32700, "Parse error")
+		return
+	}
+
+	// The body is either a single message or a batch
+	var msgs []JSONRPCRequest
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &msgs); err != nil || len(msgs) == 0 {
+			writeJSONRPCError(w, http.StatusBadRequest, -32600, "Invalid Request")
+			return
+		}
+	} else {
+		var msg JSONRPCRequest
+		if err := json.Unmarshal(trimmed, &msg); err != nil {
+			writeJSONRPCError(w, http.StatusBadRequest, -32600, "Invalid Request")
+			return
+		}
+		msgs = []JSONRPCRequest{msg}
+	}
+
+	isInitialize := false
+	for _, msg := range msgs {
+		if msg.Method == "initialize" {
+			isInitialize = true
+		}
+	}
+
+	var sess *session
+	if isInitialize {
+		if len(msgs) > 1 {
+			writeJSONRPCError(w, http.StatusBadRequest, -32600, "initialize must not be part of a batch")
+			return
+		}
+		sess = s.newSession()
+		sess.expireWhenIdle()
+		s.reaper.Do(func() { go s.closeIdleSessions() })
+		w.Header().Set(sessionHeader, sess.id)
+		log.Printf("New session: %s", sess.id)
+	} else {
+		sessionID := r.Header.Get(sessionHeader)
+		if sessionID == "" {
+			writeJSONRPCError(w, http.StatusBadRequest, -32600, "Missing "+sessionHeader+" header")
+			return
+		}
+		var ok bool
+		if sess, ok = s.lookupSession(sessionID); !ok {
+			writeJSONRPCError(w, http.StatusNotFound, -32001, "Session not found")
+			return
+		}
+	}
+	defer sess.use()()
+
+	// Notifications and client responses only need an acknowledgement
+	var requests []JSONRPCRequest
+	for _, msg := range msgs {
+		if msg.Method == "" {
+			continue
+		}
+		if msg.isNotification() {
+			s.handleRequest(sess.ctx, sess, msg)
+			continue
+		}
+		requests = append(requests, msg)
+	}
+	if len(requests) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// Stream when the client insists on it, or when it asked for progress
+	// that a single JSON response could not carry
+	if acceptsEventStream(r) && (!acceptsJSON(r) || wantsProgress(requests)) {
+		s.streamResponses(w, r, sess, requests)
+		return
+	}
+
+	ctx, cancel := sess.requestContext(r.Context())
+	defer cancel()
+
+	responses := make([]*JSONRPCResponse, 0, len(requests))
+	for _, req := range requests {
+		responses = append(responses, s.handleRequest(ctx, sess, req))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(msgs) == 1 {
+		json.NewEncoder(w).Encode(responses[0])
+		return
+	}
+	json.NewEncoder(w).Encode(responses)
+}
+
+// streamResponses answers a POST with an SSE stream that carries the
+// notifications emitted while handling the requests and closes once every
+// request in it has been responded to.
+func (s *Server) streamResponses(w http.ResponseWriter, r *http.Request, sess *session, requests []JSONRPCRequest) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Work stops if the client hangs up or the session is terminated
+	ctx, cancel := sess.requestContext(r.Context())
+	defer cancel()
+
+	// Notifications such as progress are interleaved with the responses
+	notifyChan := make(chan string, 10)
+	ctx = withNotifier(ctx, func(msg string) {
+		select {
+		case notifyChan <- msg:
+		default:
+		}
+	})
+
+	msgChan := make(chan string, len(requests))
+	for _, req := range requests {
+		go s.processRequest(ctx, sess, req, msgChan)
+	}
+
+	for pending := len(requests); pending > 0; {
+		select {
+		case msg := <-notifyChan:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+			flusher.Flush()
+		case msg := <-msgChan:
+			// Notifications sent before the response must not trail it
+			for drained := false; !drained; {
+				select {
+				case note := <-notifyChan:
+					fmt.Fprintf(w, "event: message\ndata: %s\n\n", note)
+				default:
+					drained = true
+				}
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+			flusher.Flush()
+			pending--
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func wantsProgress(requests []JSONRPCRequest) bool {
+	for _, req := range requests {
+		if progressToken(req.Params) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// handleStreamableGet opens a standalone SSE stream for server-initiated
+// messages belonging to an existing session.
+func (s *Server) handleStreamableGet(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, ok := s.lookupSession(r.Header.Get(sessionHeader))
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	defer sess.use()()
+
+	ctx := r.Context()
+	for {
+		select {
+		case msg := <-sess.messages:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+			flusher.Flush()
+		case <-sess.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) handleStreamableDelete(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(sessionHeader)
+	if sessionID == "" {
+		http.Error(w, "Missing "+sessionHeader+" header", http.StatusBadRequest)
+		return
+	}
+	if !s.closeSession(sessionID) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("Session closed: %s", sessionID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// defaultSessionIdleTimeout is the Server.SessionIdleTimeout used when it
+// is not set.
+const defaultSessionIdleTimeout = 30 * time.Minute
+
+// closeIdleSessions periodically closes the Streamable HTTP sessions that
+// have been idle for longer than s.SessionIdleTimeout. It never returns.
+func (s *Server) closeIdleSessions() {
+	timeout := s.SessionIdleTimeout
+	if timeout <= 0 {
+		timeout = defaultSessionIdleTimeout
+	}
+	ticker := time.NewTicker(max(timeout/10, time.Second))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.sessions.Range(func(key, value interface{}) bool {
+			sess := value.(*session)
+			if sess.idle() > timeout && s.closeSession(sess.id) {
+				log.Printf("Session expired: %s", sess.id)
+			}
+			return true
+		})
+	}
+}
+
+func acceptsJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return accept == "" || strings.Contains(accept, "application/json") || strings.Contains(accept, "*/*")
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+func writeJSONRPCError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(JSONRPCResponse{
+		JSONRPC: "2.0",
+		Error:   &JSONRPCError{Code: code, Message: message},
+		ID:      nullID,
+	})
+}
@@ -0,0 +1,135 @@
+package mcp
+
+import "context"
+
+// SubscriptionHandler is told when the first session subscribes to a
+// resource URI (subscribed is true) and when the last one unsubscribes.
+// Returning an error from a subscribe call rejects the subscription.
+type SubscriptionHandler func(ctx context.Context, uri string, subscribed bool) error
+
+// RegisterSubscriptionHandler enables resources/subscribe. The handler is
+// expected to call NotifyResourceUpdated whenever a watched URI changes.
+func (s *Server) RegisterSubscriptionHandler(handler SubscriptionHandler) {
+	s.subscriptionHandler = handler
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated to every
+// session subscribed to uri.
+func (s *Server) NotifyResourceUpdated(uri string) {
+	s.subscriptionsMu.Lock()
+	sessions := make([]*session, 0, len(s.subscribers[uri]))
+	for _, sess := range s.subscribers[uri] {
+		sessions = append(sessions, sess)
+	}
+	s.subscriptionsMu.Unlock()
+
+	for _, sess := range sessions {
+		sess.notify("notifications/resources/updated", map[string]interface{}{"uri": uri})
+	}
+}
+
+// subscribe adds sess to the subscribers of uri. The subscription handler
+// may take long, so it is called without holding subscriptionsMu; other
+// calls for the same uri wait until it returns.
+func (s *Server) subscribe(ctx context.Context, sess *session, uri string) error {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	if err := s.waitHandlerCall(ctx, uri); err != nil {
+		return err
+	}
+
+	subs := s.subscribers[uri]
+	if subs[sess.id] != nil {
+		return nil
+	}
+	first := len(subs) == 0
+	if first {
+		if err := s.callHandler(ctx, uri, true); err != nil {
+			return err
+		}
+	}
+
+	// closeSession closes done before collecting the subscriptions to clean
+	// up, so a subscription recorded after that would never be removed
+	sess.mu.Lock()
+	closed := false
+	select {
+	case <-sess.done:
+		closed = true
+	default:
+		sess.subscriptions[uri] = true
+	}
+	sess.mu.Unlock()
+	if closed {
+		if first {
+			s.callHandler(context.Background(), uri, false)
+		}
+		return context.Canceled
+	}
+
+	if first {
+		subs = make(map[string]*session)
+		s.subscribers[uri] = subs
+	}
+	subs[sess.id] = sess
+	return nil
+}
+
+func (s *Server) unsubscribe(ctx context.Context, sess *session, uri string) error {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	if err := s.waitHandlerCall(ctx, uri); err != nil {
+		return err
+	}
+
+	sess.mu.Lock()
+	delete(sess.subscriptions, uri)
+	sess.mu.Unlock()
+
+	subs := s.subscribers[uri]
+	if subs[sess.id] == nil {
+		return nil
+	}
+	delete(subs, sess.id)
+	if len(subs) == 0 {
+		delete(s.subscribers, uri)
+		return s.callHandler(ctx, uri, false)
+	}
+	return nil
+}
+
+// waitHandlerCall waits until no subscription handler call for uri is in
+// progress. It must be called with subscriptionsMu held, which it releases
+// while waiting.
+func (s *Server) waitHandlerCall(ctx context.Context, uri string) error {
+	for {
+		done := s.handlerCalls[uri]
+		if done == nil {
+			return nil
+		}
+		s.subscriptionsMu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+		s.subscriptionsMu.Lock()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// callHandler calls the subscription handler for uri with subscriptionsMu
+// released. Until it returns, the uri is reserved: waitHandlerCall blocks.
+func (s *Server) callHandler(ctx context.Context, uri string, subscribed bool) error {
+	done := make(chan struct{})
+	s.handlerCalls[uri] = done
+	s.subscriptionsMu.Unlock()
+
+	err := s.subscriptionHandler(ctx, uri, subscribed)
+
+	s.subscriptionsMu.Lock()
+	delete(s.handlerCalls, uri)
+	close(done)
+	return err
+}
@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ArgumentError reports a tool argument that is missing or does not match
+// the tool's input schema.
+type ArgumentError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("invalid argument %q: %s", e.Field, e.Reason)
+}
+
+// AddTool registers a tool backed by a typed function. The input schema is
+// derived from In, which must be a struct (see SchemaFor), unless the tool
+// already provides one. Arguments are decoded into In before fn runs.
+//
+// A string Out is returned as text. A struct Out also gets an output schema
+// and is returned as structuredContent, with its JSON as the text fallback.
+// Any other Out is returned as JSON text only.
+func AddTool[In, Out any](s *Server, tool Tool, fn func(ctx context.Context, in In) (Out, error)) {
+	inType := reflect.TypeOf((*In)(nil)).Elem()
+	if indirect(inType).Kind() != reflect.Struct {
+		panic(fmt.Sprintf("mcp: input type of tool %q must be a struct, got %s", tool.Name, inType))
+	}
+
+	schema := SchemaFor(inType)
+	schema.AdditionalProperties = false
+	if tool.InputSchema == nil {
+		tool.InputSchema = schema
+	}
+
+	outType := reflect.TypeOf((*Out)(nil)).Elem()
+	structured := indirect(outType).Kind() == reflect.Struct
+	if structured && tool.OutputSchema == nil {
+		tool.OutputSchema = SchemaFor(outType)
+	}
+
+	s.RegisterTool(tool, func(ctx context.Context, arguments map[string]interface{}) (*CallToolResult, error) {
+		var in In
+		if err := decodeArguments(arguments, schema, &in); err != nil {
+			return nil, err
+		}
+
+		out, err := fn(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		return toolResult(out, structured)
+	})
+}
+
+// decodeArguments checks required arguments and decodes the rest into v,
+// rejecting unknown arguments and values of the wrong type.
+func decodeArguments(arguments map[string]interface{}, schema *Schema, v interface{}) error {
+	for _, name := range schema.Required {
+		if _, ok := arguments[name]; !ok {
+			return &ArgumentError{Field: name, Reason: "is required"}
+		}
+	}
+
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &ArgumentError{Field: typeErr.Field, Reason: "must be " + describeType(typeErr.Type)}
+		}
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return &ArgumentError{Field: strings.Trim(field, `"`), Reason: "is not a known argument"}
+		}
+		return err
+	}
+	return nil
+}
+
+func describeType(t reflect.Type) string {
+	if typ := SchemaFor(t).Type; typ != "" {
+		return article(typ)
+	}
+	return "a valid value"
+}
+
+func toolResult(out interface{}, structured bool) (*CallToolResult, error) {
+	if text, ok := out.(string); ok {
+		return &CallToolResult{
+			Content: []Content{{Type: "text", Text: text}},
+		}, nil
+	}
+
+	outJSON, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	result := &CallToolResult{
+		Content: []Content{{Type: "text", Text: string(outJSON)}},
+	}
+	if structured {
+		result.StructuredContent = out
+	}
+	return result, nil
+}
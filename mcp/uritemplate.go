@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// uriTemplate is a minimal RFC 6570 level 1 template: every {name} matches a
+// single non-empty segment that contains no '/'.
+type uriTemplate struct {
+	raw   string
+	names []string
+	re    *regexp.Regexp
+}
+
+var templateVarPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+func compileURITemplate(raw string) (*uriTemplate, error) {
+	t := &uriTemplate{raw: raw}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range templateVarPattern.FindAllStringSubmatchIndex(raw, -1) {
+		pattern.WriteString(regexp.QuoteMeta(raw[last:loc[0]]))
+		pattern.WriteString("([^/?#]+)")
+		t.names = append(t.names, raw[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(raw[last:]))
+	pattern.WriteString("$")
+
+	if strings.ContainsAny(raw[last:], "{}") {
+		return nil, fmt.Errorf("unsupported URI template %q", raw)
+	}
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, err
+	}
+	t.re = re
+	return t, nil
+}
+
+// Match extracts the template variables from uri, unescaping each value.
+func (t *uriTemplate) Match(uri string) (map[string]string, bool) {
+	m := t.re.FindStringSubmatch(uri)
+	if m == nil {
+		return nil, false
+	}
+
+	vars := make(map[string]string, len(t.names))
+	for i, name := range t.names {
+		value, err := url.PathUnescape(m[i+1])
+		if err != nil {
+			return nil, false
+		}
+		vars[name] = value
+	}
+	return vars, true
+}
+
+// ExpandURITemplate substitutes vars into template, escaping each value.
+func ExpandURITemplate(template string, vars map[string]string) string {
+	return templateVarPattern.ReplaceAllStringFunc(template, func(v string) string {
+		return url.PathEscape(vars[v[1:len(v)-1]])
+	})
+}
+
+// MatchURITemplate reports whether uri matches template and returns the
+// unescaped template variables.
+func MatchURITemplate(template, uri string) (map[string]string, bool) {
+	t, err := compileURITemplate(template)
+	if err != nil {
+		return nil, false
+	}
+	return t.Match(uri)
+}
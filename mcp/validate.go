@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// toSchema normalizes a tool's InputSchema, which may be a *Schema or any
+// value that marshals to JSON Schema, such as a hand-written map.
+func toSchema(v interface{}) (*Schema, error) {
+	if schema, ok := v.(*Schema); ok {
+		return schema, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// validateArguments checks tool arguments against the tool's input schema.
+// The returned *ArgumentError names the offending field by its path, e.g.
+// "definitions[2]".
+func validateArguments(schema *Schema, arguments map[string]interface{}) error {
+	return validateValue(schema, arguments, "")
+}
+
+func validateValue(schema *Schema, value interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Type != "" && !hasType(value, schema.Type) {
+		return &ArgumentError{Field: path, Reason: "must be " + article(schema.Type)}
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &ArgumentError{Field: path, Reason: fmt.Sprintf("must be one of %v", schema.Enum)}
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				return &ArgumentError{Field: path, Reason: "must be an RFC 3339 date-time"}
+			}
+		}
+	case float64:
+		if schema.Minimum != nil && v < *schema.Minimum {
+			return &ArgumentError{Field: path, Reason: fmt.Sprintf("must be >= %v", *schema.Minimum)}
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			return &ArgumentError{Field: path, Reason: fmt.Sprintf("must be <= %v", *schema.Maximum)}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := validateValue(schema.Items, item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				return &ArgumentError{Field: joinPath(path, name), Reason: "is required"}
+			}
+		}
+
+		// Sorted so that the reported error is deterministic
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			propPath := joinPath(path, name)
+			if prop, ok := schema.Properties[name]; ok {
+				if err := validateValue(prop, v[name], propPath); err != nil {
+					return err
+				}
+				continue
+			}
+
+			allowed, extra := additionalProperties(schema.AdditionalProperties)
+			if !allowed {
+				return &ArgumentError{Field: propPath, Reason: "is not a known argument"}
+			}
+			if err := validateValue(extra, v[name], propPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasType(value interface{}, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	default:
+		// Unknown types are not ours to reject
+		return true
+	}
+}
+
+// additionalProperties interprets the additionalProperties keyword, which is
+// either a boolean or a schema for the extra properties.
+func additionalProperties(v interface{}) (bool, *Schema) {
+	switch ap := v.(type) {
+	case nil:
+		return true, nil
+	case bool:
+		return ap, nil
+	default:
+		schema, err := toSchema(ap)
+		if err != nil {
+			return true, nil
+		}
+		return true, schema
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func article(typ string) string {
+	switch typ {
+	case "integer", "array", "object":
+		return "an " + typ
+	default:
+		return "a " + typ
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yildizozan/adomcp/azuredevops"
+	"github.com/yildizozan/adomcp/mcp"
+)
+
+// promptLogTailLines is how many trailing lines of each log are embedded in a
+// prompt; failures are almost always reported at the end of a log.
+const promptLogTailLines = 200
+
+// registerPrompts ships the built-in CI triage prompts. The logs embedded in
+// a prompt take at most maxOutput characters in total.
+func registerPrompts(server *mcp.Server, client *azuredevops.Client, maxOutput int) {
+	server.RegisterPrompt(mcp.Prompt{
+		Name:        "diagnose_failing_build",
+		Description: "Diagnose why a build failed, using its details and logs",
+		Arguments: []mcp.PromptArgument{
+			{Name: "buildId", Description: "ID of the build (or use url)"},
+			{Name: "url", Description: "Full URL of the build (alternative to buildId)"},
+			{Name: "project", Description: "Project name (optional, overrides default)"},
+		},
+	}, func(ctx context.Context, args map[string]string) (*mcp.GetPromptResult, error) {
+		project, buildId, err := resolvePromptTarget(args, "buildId", azuredevops.ResourceBuild)
+		if err != nil {
+			return nil, err
+		}
+
+		build, err := client.GetBuild(ctx, project, buildId)
+		if err != nil {
+			return nil, describeError(err)
+		}
+		// The failed steps tell why a build failed; only fall back to every
+		// log if none is marked as failed
+		logs, err := client.GetFailedBuildLogs(withProgress(ctx), project, buildId)
+		if err == nil && logs == "" {
+			logs, err = client.GetBuildLogs(withProgress(ctx), project, buildId)
+		}
+		if err != nil {
+			return nil, describeError(err)
+		}
+		logs = limitLogs(tailLogSections(logs, promptLogTailLines), maxOutput, "get_build_logs")
+
+		return &mcp.GetPromptResult{
+			Description: fmt.Sprintf("Diagnose build %s", build.BuildNumber),
+			Messages: []mcp.PromptMessage{
+				userText(fmt.Sprintf("Build %d (%s %s) of pipeline %q did not succeed. "+
+					"Using the build details and the end of the logs of its failed steps below, identify the root cause of the failure: "+
+					"name the failing step, quote the relevant error lines, explain what went wrong and suggest a fix. "+
+					"Distinguish the first real error from follow-up failures.",
+					build.Id, build.Status, build.Result, build.Definition.Name)),
+				userResource(jsonContents(buildURI(build.Project.Name, buildId), build)),
+				userResource(textContents(buildLogsURI(build.Project.Name, buildId), logs)),
+			},
+		}, nil
+	})
+
+	server.RegisterPrompt(mcp.Prompt{
+		Name:        "summarize_release",
+		Description: "Summarize a release and the outcome of each environment",
+		Arguments: []mcp.PromptArgument{
+			{Name: "releaseId", Description: "ID of the release (or use url)"},
+			{Name: "url", Description: "Full URL of the release (alternative to releaseId)"},
+			{Name: "project", Description: "Project name (optional, overrides default)"},
+		},
+	}, func(ctx context.Context, args map[string]string) (*mcp.GetPromptResult, error) {
+		project, releaseId, err := resolvePromptTarget(args, "releaseId", azuredevops.ResourceRelease)
+		if err != nil {
+			return nil, err
+		}
+
+		release, err := client.GetRelease(ctx, project, releaseId)
+		if err != nil {
+			return nil, describeError(err)
+		}
+		logs, err := client.GetReleaseLogs(withProgress(ctx), project, releaseId)
+		if err != nil {
+			return nil, describeError(err)
+		}
+		logs = limitLogs(tailLogSections(logs, promptLogTailLines), maxOutput, "get_release_logs")
+
+		return &mcp.GetPromptResult{
+			Description: fmt.Sprintf("Summarize release %s", release.Name),
+			Messages: []mcp.PromptMessage{
+				userText(fmt.Sprintf("Summarize release %q of %q. "+
+					"For every environment, state whether the deployment succeeded, failed or was skipped, "+
+					"and for failures quote the relevant task log lines and suggest a next step. "+
+					"Finish with a one-line overall status.",
+					release.Name, release.ReleaseDefinition.Name)),
+				userResource(jsonContents(releaseURI(release.ProjectReference.Name, releaseId), release)),
+				userResource(textContents(releaseLogsURI(release.ProjectReference.Name, releaseId), logs)),
+			},
+		}, nil
+	})
+
+	server.RegisterPrompt(mcp.Prompt{
+		Name:        "compare_builds",
+		Description: "Compare two builds to find what changed between a good and a bad run",
+		Arguments: []mcp.PromptArgument{
+			{Name: "baseBuildId", Description: "ID of the reference (usually last good) build", Required: true},
+			{Name: "headBuildId", Description: "ID of the build to compare (usually the failing one)", Required: true},
+			{Name: "project", Description: "Project name (optional, overrides default)"},
+		},
+	}, func(ctx context.Context, args map[string]string) (*mcp.GetPromptResult, error) {
+		project := args["project"]
+		baseId, err := strconv.Atoi(args["baseBuildId"])
+		if err != nil {
+			return nil, fmt.Errorf("baseBuildId must be an integer")
+		}
+		headId, err := strconv.Atoi(args["headBuildId"])
+		if err != nil {
+			return nil, fmt.Errorf("headBuildId must be an integer")
+		}
+
+		messages := []mcp.PromptMessage{
+			userText(fmt.Sprintf("Compare build %d (base) with build %d (head). "+
+				"Point out differences in outcome, duration and the steps that ran, "+
+				"quote log lines that appear only in the head build, and explain the most likely cause of any regression.",
+				baseId, headId)),
+		}
+		for _, id := range []int{baseId, headId} {
+			build, err := client.GetBuild(ctx, project, id)
+			if err != nil {
+				return nil, describeError(err)
+			}
+			logs, err := client.GetBuildLogs(ctx, project, id)
+			if err != nil {
+				return nil, describeError(err)
+			}
+			// Both builds share the output limit
+			logs = limitLogs(tailLogSections(logs, promptLogTailLines), maxOutput/2, "get_build_logs")
+			messages = append(messages,
+				userResource(jsonContents(buildURI(build.Project.Name, id), build)),
+				userResource(textContents(buildLogsURI(build.Project.Name, id), logs)),
+			)
+		}
+
+		return &mcp.GetPromptResult{
+			Description: fmt.Sprintf("Compare builds %d and %d", baseId, headId),
+			Messages:    messages,
+		}, nil
+	})
+}
+
+// resolvePromptTarget returns the project and ID a prompt refers to, either
+// from the idArg argument or by parsing the url argument.
+func resolvePromptTarget(args map[string]string, idArg string, want azuredevops.ResourceType) (string, int, error) {
+	if urlStr := args["url"]; urlStr != "" {
+		parsed, err := azuredevops.ParseURL(urlStr)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to parse URL: %v", err)
+		}
+		if parsed.Type != want {
+			return "", 0, fmt.Errorf("url does not point to the expected resource type")
+		}
+		return parsed.Project, parsed.ID, nil
+	}
+
+	if args[idArg] == "" {
+		return "", 0, fmt.Errorf("either %s or url is required", idArg)
+	}
+	id, err := strconv.Atoi(args[idArg])
+	if err != nil {
+		return "", 0, fmt.Errorf("%s must be an integer", idArg)
+	}
+	return args["project"], id, nil
+}
+
+// tailLogSections keeps the last maxLines lines of every section of a
+// concatenated log (see isSectionHeader).
+func tailLogSections(logs string, maxLines int) string {
+	var out strings.Builder
+	var section []string
+
+	flush := func() {
+		if len(section) == 0 {
+			return
+		}
+		header, body := section[0], section[1:]
+		out.WriteString(header + "\n")
+		if len(body) > maxLines {
+			out.WriteString(fmt.Sprintf("[... %d lines omitted ...]\n", len(body)-maxLines))
+			body = body[len(body)-maxLines:]
+		}
+		for _, line := range body {
+			out.WriteString(line + "\n")
+		}
+		section = nil
+	}
+
+	for _, line := range strings.Split(logs, "\n") {
+		if isSectionHeader(line) {
+			flush()
+		}
+		section = append(section, line)
+	}
+	flush()
+
+	return out.String()
+}
+
+func userText(text string) mcp.PromptMessage {
+	return mcp.PromptMessage{
+		Role:    "user",
+		Content: mcp.Content{Type: "text", Text: text},
+	}
+}
+
+func userResource(contents mcp.ResourceContents) mcp.PromptMessage {
+	return mcp.PromptMessage{
+		Role:    "user",
+		Content: mcp.Content{Type: "resource", Resource: &contents},
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/yildizozan/adomcp/azuredevops"
+	"github.com/yildizozan/adomcp/mcp"
+)
+
+const (
+	buildURITemplate       = "ado://{project}/builds/{buildId}"
+	buildLogsURITemplate   = "ado://{project}/builds/{buildId}/logs"
+	buildLogURITemplate    = "ado://{project}/builds/{buildId}/logs/{logId}"
+	releaseURITemplate     = "ado://{project}/releases/{releaseId}"
+	releaseLogsURITemplate = "ado://{project}/releases/{releaseId}/logs"
+)
+
+// registerResources exposes builds, releases and their logs as MCP resources.
+// Logs are truncated to maxOutput characters.
+func registerResources(server *mcp.Server, client *azuredevops.Client, maxOutput int) {
+	server.RegisterResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: buildURITemplate,
+		Name:        "Build",
+		Description: "Build details",
+		MimeType:    "application/json",
+	}, func(ctx context.Context, uri string, vars map[string]string) (*mcp.ReadResourceResult, error) {
+		buildId, err := strconv.Atoi(vars["buildId"])
+		if err != nil {
+			return nil, mcp.ErrResourceNotFound
+		}
+
+		build, err := client.GetBuild(ctx, vars["project"], buildId)
+		if err != nil {
+			return nil, resourceError(err)
+		}
+		return jsonResource(uri, build)
+	})
+
+	server.RegisterResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: buildLogsURITemplate,
+		Name:        "Build logs",
+		Description: "All logs of a build, concatenated",
+		MimeType:    "text/plain",
+	}, func(ctx context.Context, uri string, vars map[string]string) (*mcp.ReadResourceResult, error) {
+		buildId, err := strconv.Atoi(vars["buildId"])
+		if err != nil {
+			return nil, mcp.ErrResourceNotFound
+		}
+
+		logs, err := client.GetBuildLogs(withProgress(ctx), vars["project"], buildId)
+		if err != nil {
+			return nil, resourceError(err)
+		}
+		return textResource(uri, limitLogs(logs, maxOutput, "get_build_logs")), nil
+	})
+
+	server.RegisterResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: buildLogURITemplate,
+		Name:        "Build log",
+		Description: "A single log of a build",
+		MimeType:    "text/plain",
+	}, func(ctx context.Context, uri string, vars map[string]string) (*mcp.ReadResourceResult, error) {
+		buildId, err := strconv.Atoi(vars["buildId"])
+		if err != nil {
+			return nil, mcp.ErrResourceNotFound
+		}
+		logId, err := strconv.Atoi(vars["logId"])
+		if err != nil {
+			return nil, mcp.ErrResourceNotFound
+		}
+
+		content, err := client.GetBuildLog(ctx, vars["project"], buildId, logId)
+		if err != nil {
+			return nil, resourceError(err)
+		}
+		return textResource(uri, limitLogs(content, maxOutput, "get_build_log")), nil
+	})
+
+	server.RegisterResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: releaseURITemplate,
+		Name:        "Release",
+		Description: "Release details",
+		MimeType:    "application/json",
+	}, func(ctx context.Context, uri string, vars map[string]string) (*mcp.ReadResourceResult, error) {
+		releaseId, err := strconv.Atoi(vars["releaseId"])
+		if err != nil {
+			return nil, mcp.ErrResourceNotFound
+		}
+
+		release, err := client.GetRelease(ctx, vars["project"], releaseId)
+		if err != nil {
+			return nil, resourceError(err)
+		}
+		return jsonResource(uri, release)
+	})
+
+	server.RegisterResourceTemplate(mcp.ResourceTemplate{
+		URITemplate: releaseLogsURITemplate,
+		Name:        "Release logs",
+		Description: "Logs of every task in every environment of a release",
+		MimeType:    "text/plain",
+	}, func(ctx context.Context, uri string, vars map[string]string) (*mcp.ReadResourceResult, error) {
+		releaseId, err := strconv.Atoi(vars["releaseId"])
+		if err != nil {
+			return nil, mcp.ErrResourceNotFound
+		}
+
+		logs, err := client.GetReleaseLogs(withProgress(ctx), vars["project"], releaseId)
+		if err != nil {
+			return nil, resourceError(err)
+		}
+		return textResource(uri, limitLogs(logs, maxOutput, "get_release_logs")), nil
+	})
+
+	// Advertise the most recent builds and releases as concrete resources
+	server.RegisterResourceLister(func(ctx context.Context) ([]mcp.Resource, error) {
+		page, err := client.GetBuilds(ctx, "", azuredevops.BuildQuery{Top: 10})
+		if err != nil {
+			return nil, err
+		}
+
+		resources := make([]mcp.Resource, 0, len(page.Builds))
+		for _, b := range page.Builds {
+			resources = append(resources, mcp.Resource{
+				URI:         buildURI(b.Project.Name, b.Id),
+				Name:        b.Definition.Name + " " + b.BuildNumber,
+				Description: "Build " + b.BuildNumber + " (" + b.Status + " " + b.Result + ")",
+				MimeType:    "application/json",
+			})
+		}
+		return resources, nil
+	})
+
+	server.RegisterResourceLister(func(ctx context.Context) ([]mcp.Resource, error) {
+		page, err := client.GetReleases(ctx, "", azuredevops.ReleaseQuery{Top: 10})
+		if err != nil {
+			return nil, err
+		}
+
+		resources := make([]mcp.Resource, 0, len(page.Releases))
+		for _, r := range page.Releases {
+			resources = append(resources, mcp.Resource{
+				URI:         releaseURI(r.ProjectReference.Name, r.Id),
+				Name:        r.ReleaseDefinition.Name + " " + r.Name,
+				Description: "Release " + r.Name + " (" + r.Status + ")",
+				MimeType:    "application/json",
+			})
+		}
+		return resources, nil
+	})
+}
+
+// registerSubscriptions lets sessions subscribe to build resources. The
+// returned watcher polls subscribed builds and must be started by the caller.
+func registerSubscriptions(server *mcp.Server, client *azuredevops.Client, interval time.Duration) *azuredevops.BuildWatcher {
+	watcher := azuredevops.NewBuildWatcher(client, interval, func(project string, build *azuredevops.Build) {
+		server.NotifyResourceUpdated(buildURI(project, build.Id))
+	})
+
+	server.RegisterSubscriptionHandler(func(ctx context.Context, uri string, subscribed bool) error {
+		vars, ok := mcp.MatchURITemplate(buildURITemplate, uri)
+		if !ok {
+			return fmt.Errorf("subscriptions are only supported for %s", buildURITemplate)
+		}
+		buildId, err := strconv.Atoi(vars["buildId"])
+		if err != nil {
+			return fmt.Errorf("invalid build ID %q", vars["buildId"])
+		}
+		// Updates are sent for the canonical URI, which must be the one the
+		// client subscribed to
+		if canonical := buildURI(vars["project"], buildId); uri != canonical {
+			return fmt.Errorf("subscribe to %s instead", canonical)
+		}
+
+		if !subscribed {
+			watcher.Unwatch(vars["project"], buildId)
+			return nil
+		}
+		return describeError(watcher.Watch(ctx, vars["project"], buildId))
+	})
+
+	return watcher
+}
+
+func buildURI(project string, buildId int) string {
+	return mcp.ExpandURITemplate(buildURITemplate, map[string]string{
+		"project": project,
+		"buildId": strconv.Itoa(buildId),
+	})
+}
+
+func releaseURI(project string, releaseId int) string {
+	return mcp.ExpandURITemplate(releaseURITemplate, map[string]string{
+		"project":   project,
+		"releaseId": strconv.Itoa(releaseId),
+	})
+}
+
+func buildLogsURI(project string, buildId int) string {
+	return mcp.ExpandURITemplate(buildLogsURITemplate, map[string]string{
+		"project": project,
+		"buildId": strconv.Itoa(buildId),
+	})
+}
+
+func releaseLogsURI(project string, releaseId int) string {
+	return mcp.ExpandURITemplate(releaseLogsURITemplate, map[string]string{
+		"project":   project,
+		"releaseId": strconv.Itoa(releaseId),
+	})
+}
+
+func jsonResource(uri string, v interface{}) (*mcp.ReadResourceResult, error) {
+	return &mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContents{jsonContents(uri, v)},
+	}, nil
+}
+
+func textResource(uri, text string) *mcp.ReadResourceResult {
+	return &mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContents{textContents(uri, text)},
+	}
+}
+
+func jsonContents(uri string, v interface{}) mcp.ResourceContents {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	return mcp.ResourceContents{URI: uri, MimeType: "application/json", Text: string(data)}
+}
+
+func textContents(uri, text string) mcp.ResourceContents {
+	return mcp.ResourceContents{URI: uri, MimeType: "text/plain", Text: text}
+}
@@ -0,0 +1,576 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yildizozan/adomcp/azuredevops"
+	"github.com/yildizozan/adomcp/mcp"
+)
+
+type listBuildsArgs struct {
+	Top            int       `json:"top,omitempty" description:"Number of builds to retrieve (default 10)" jsonschema:"minimum=1,maximum=1000"`
+	Cursor         string    `json:"cursor,omitempty" description:"nextCursor from a previous call, to fetch the following page"`
+	Definitions    []int     `json:"definitions,omitempty" description:"Only builds of these pipeline definition IDs"`
+	BranchName     string    `json:"branchName,omitempty" description:"Only builds of this branch, e.g. main or refs/heads/main"`
+	ResultFilter   string    `json:"resultFilter,omitempty" description:"Only builds with this result" jsonschema:"enum=succeeded|partiallySucceeded|failed|canceled"`
+	StatusFilter   string    `json:"statusFilter,omitempty" description:"Only builds with this status" jsonschema:"enum=inProgress|completed|cancelling|postponed|notStarted|all"`
+	ReasonFilter   string    `json:"reasonFilter,omitempty" description:"Only builds queued for this reason" jsonschema:"enum=manual|individualCI|batchedCI|schedule|userCreated|validateShelveset|checkInShelveset|pullRequest|buildCompletion|resourceTrigger|triggered|all"`
+	RequestedFor   string    `json:"requestedFor,omitempty" description:"Only builds requested for this user (display name, email or ID)"`
+	TagFilters     []string  `json:"tagFilters,omitempty" description:"Only builds carrying all of these tags"`
+	MinTime        time.Time `json:"minTime,omitzero" description:"Only builds that finished (or were queued, depending on queryOrder) after this RFC 3339 time"`
+	MaxTime        time.Time `json:"maxTime,omitzero" description:"Only builds that finished (or were queued, depending on queryOrder) before this RFC 3339 time"`
+	QueryOrder     string    `json:"queryOrder,omitempty" description:"Sort order of the results" jsonschema:"enum=finishTimeAscending|finishTimeDescending|queueTimeAscending|queueTimeDescending|startTimeAscending|startTimeDescending"`
+	RepositoryId   string    `json:"repositoryId,omitempty" description:"Only builds of this repository"`
+	RepositoryType string    `json:"repositoryType,omitempty" description:"Type of repositoryId (default TfsGit)"`
+	Project        string    `json:"project,omitempty" description:"Project name (optional, overrides default)"`
+}
+
+type listReleasesArgs struct {
+	Top                     int       `json:"top,omitempty" description:"Number of releases to retrieve (default 10)" jsonschema:"minimum=1,maximum=1000"`
+	Cursor                  string    `json:"cursor,omitempty" description:"nextCursor from a previous call, to fetch the following page"`
+	DefinitionId            int       `json:"definitionId,omitempty" description:"Only releases of this release definition ID" jsonschema:"minimum=1"`
+	DefinitionEnvironmentId int       `json:"definitionEnvironmentId,omitempty" description:"Only releases deploying to this definition environment ID (requires definitionId)" jsonschema:"minimum=1"`
+	StatusFilter            string    `json:"statusFilter,omitempty" description:"Only releases with this status" jsonschema:"enum=draft|active|abandoned"`
+	EnvironmentStatusFilter []string  `json:"environmentStatusFilter,omitempty" description:"Only releases with an environment in any of these states" jsonschema:"enum=notStarted|inProgress|succeeded|canceled|rejected|queued|scheduled|partiallySucceeded"`
+	CreatedBy               string    `json:"createdBy,omitempty" description:"Only releases created by this user (display name or ID)"`
+	MinCreatedTime          time.Time `json:"minCreatedTime,omitzero" description:"Only releases created after this RFC 3339 time"`
+	MaxCreatedTime          time.Time `json:"maxCreatedTime,omitzero" description:"Only releases created before this RFC 3339 time"`
+	SourceBranchFilter      string    `json:"sourceBranchFilter,omitempty" description:"Only releases whose primary artifact comes from this branch, e.g. main or refs/heads/main"`
+	ArtifactTypeId          string    `json:"artifactTypeId,omitempty" description:"Type of artifactVersionId, e.g. Build"`
+	ArtifactVersionId       string    `json:"artifactVersionId,omitempty" description:"Only releases of this artifact version; for build artifacts this is the build ID"`
+	SearchText              string    `json:"searchText,omitempty" description:"Only releases whose name contains this text"`
+	Project                 string    `json:"project,omitempty" description:"Project name (optional, overrides default)"`
+}
+
+type buildArgs struct {
+	BuildId int    `json:"buildId" description:"ID of the build" jsonschema:"minimum=1"`
+	Project string `json:"project,omitempty" description:"Project name (optional, overrides default)"`
+}
+
+type buildLogsArgs struct {
+	BuildId    int    `json:"buildId" description:"ID of the build" jsonschema:"minimum=1"`
+	OnlyFailed bool   `json:"onlyFailed,omitempty" description:"Only fetch the logs of failed or canceled tasks and their jobs, found via the build timeline"`
+	Project    string `json:"project,omitempty" description:"Project name (optional, overrides default)"`
+	logOutputArgs
+}
+
+type buildLogArgs struct {
+	BuildId    int    `json:"buildId" description:"ID of the build" jsonschema:"minimum=1"`
+	LogId      int    `json:"logId" description:"ID of the log, as listed by get_build_timeline" jsonschema:"minimum=1"`
+	StartLine  int    `json:"startLine,omitempty" description:"First line to return, counting from 1" jsonschema:"minimum=1"`
+	EndLine    int    `json:"endLine,omitempty" description:"Last line to return" jsonschema:"minimum=1"`
+	Tail       int    `json:"tail,omitempty" description:"Return only the last N lines (not combinable with startLine/endLine)" jsonschema:"minimum=1"`
+	Grep       string `json:"grep,omitempty" description:"Regular expression (RE2 syntax); only matching lines and their context are returned"`
+	Context    *int   `json:"context,omitempty" description:"Lines of context around each grep match (default 3)" jsonschema:"minimum=0,maximum=500"`
+	MaxMatches int    `json:"maxMatches,omitempty" description:"Stop after this many grep matches (default 50)" jsonschema:"minimum=1"`
+	Project    string `json:"project,omitempty" description:"Project name (optional, overrides default)"`
+	logOutputArgs
+}
+
+type summarizeFailureArgs struct {
+	BuildId   int    `json:"buildId,omitempty" description:"ID of the build (or use releaseId or url)" jsonschema:"minimum=1"`
+	ReleaseId int    `json:"releaseId,omitempty" description:"ID of the release (or use buildId or url)" jsonschema:"minimum=1"`
+	URL       string `json:"url,omitempty" description:"Full URL of the build or release (alternative to buildId and releaseId)"`
+	Project   string `json:"project,omitempty" description:"Project name (optional, overrides default)"`
+}
+
+type releaseArgs struct {
+	ReleaseId int    `json:"releaseId" description:"ID of the release" jsonschema:"minimum=1"`
+	Project   string `json:"project,omitempty" description:"Project name (optional, overrides default)"`
+}
+
+type releaseLogsArgs struct {
+	ReleaseId int    `json:"releaseId" description:"ID of the release" jsonschema:"minimum=1"`
+	Project   string `json:"project,omitempty" description:"Project name (optional, overrides default)"`
+	logOutputArgs
+}
+
+type urlArgs struct {
+	URL        string `json:"url" description:"The full URL of the build or release"`
+	OnlyFailed *bool  `json:"onlyFailed,omitempty" description:"For builds, only fetch the logs of failed or canceled tasks and their jobs (default true)"`
+	logOutputArgs
+}
+
+// buildList and releaseList wrap list results, since structured tool output
+// must be an object. NextCursor is set when more results are available.
+type buildList struct {
+	Builds     []azuredevops.Build `json:"builds"`
+	NextCursor string              `json:"nextCursor,omitempty"`
+}
+
+type releaseList struct {
+	Releases   []azuredevops.Release `json:"releases"`
+	NextCursor string                `json:"nextCursor,omitempty"`
+}
+
+// registerTools exposes the ADO client operations as MCP tools. Log tools
+// return at most maxOutput characters unless a call asks otherwise.
+func registerTools(server *mcp.Server, client *azuredevops.Client, maxOutput int) {
+	addTool(server, mcp.Tool{
+		Name:        "list_builds",
+		Description: "List recent builds, optionally filtered by pipeline, branch, result, status, reason, requester, tags and time range",
+		Annotations: readOnly("List builds"),
+	}, func(ctx context.Context, args listBuildsArgs) (*buildList, error) {
+		token, err := decodeCursor(args.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := client.GetBuilds(ctx, args.Project, azuredevops.BuildQuery{
+			Top:               topOrDefault(args.Top),
+			ContinuationToken: token,
+			Definitions:       args.Definitions,
+			BranchName:        branchRef(args.BranchName),
+			ResultFilter:      args.ResultFilter,
+			StatusFilter:      args.StatusFilter,
+			ReasonFilter:      args.ReasonFilter,
+			RequestedFor:      args.RequestedFor,
+			TagFilters:        args.TagFilters,
+			MinTime:           args.MinTime,
+			MaxTime:           args.MaxTime,
+			QueryOrder:        args.QueryOrder,
+			RepositoryId:      args.RepositoryId,
+			RepositoryType:    args.RepositoryType,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &buildList{Builds: page.Builds, NextCursor: encodeCursor(page.ContinuationToken)}, nil
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "get_build",
+		Description: "Get build details",
+		Annotations: readOnly("Get build"),
+	}, func(ctx context.Context, args buildArgs) (*azuredevops.Build, error) {
+		return client.GetBuild(ctx, args.Project, args.BuildId)
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "get_build_logs",
+		Description: "Get build logs, either all of them or only those of failed tasks",
+		Annotations: readOnly("Get build logs"),
+	}, func(ctx context.Context, args buildLogsArgs) (string, error) {
+		logs, err := buildLogs(withProgress(ctx), client, args.Project, args.BuildId, args.OnlyFailed)
+		if err != nil {
+			return "", err
+		}
+		return args.limit(logs, maxOutput)
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "get_build_log",
+		Description: "Get part of a single build log with line numbers: a line range, the last N lines, or the lines matching a regular expression with context",
+		Annotations: readOnly("Get build log"),
+	}, func(ctx context.Context, args buildLogArgs) (string, error) {
+		if args.Tail > 0 && (args.StartLine > 0 || args.EndLine > 0) {
+			return "", &mcp.ArgumentError{Field: "tail", Reason: "cannot be combined with startLine or endLine"}
+		}
+		if args.EndLine > 0 && args.EndLine < args.StartLine {
+			return "", &mcp.ArgumentError{Field: "endLine", Reason: "must be >= startLine"}
+		}
+		var re *regexp.Regexp
+		if args.Grep != "" {
+			var err error
+			if re, err = regexp.Compile(args.Grep); err != nil {
+				return "", &mcp.ArgumentError{Field: "grep", Reason: "is not a valid regular expression: " + err.Error()}
+			}
+		}
+
+		startLine, endLine := args.StartLine, args.EndLine
+		if args.Tail > 0 {
+			lineCount, err := buildLogLineCount(ctx, client, args.Project, args.BuildId, args.LogId)
+			if err != nil {
+				return "", err
+			}
+			startLine = max(lineCount-args.Tail+1, 1)
+		}
+
+		content, err := client.GetBuildLogLines(ctx, args.Project, args.BuildId, args.LogId, startLine, endLine)
+		if err != nil {
+			return "", err
+		}
+		lines := splitLines(content)
+		firstLine := max(startLine, 1)
+		// The line count may be stale for a running build, or unknown
+		if args.Tail > 0 && len(lines) > args.Tail {
+			firstLine += len(lines) - args.Tail
+			lines = lines[len(lines)-args.Tail:]
+		}
+
+		if re == nil {
+			if len(lines) == 0 {
+				return fmt.Sprintf("Log %d has no lines in the requested range.", args.LogId), nil
+			}
+			return args.limit(numberLines(lines, firstLine), maxOutput)
+		}
+
+		contextLines := 3
+		if args.Context != nil {
+			contextLines = *args.Context
+		}
+		maxMatches := args.MaxMatches
+		if maxMatches == 0 {
+			maxMatches = 50
+		}
+		out, matches := grepLines(lines, firstLine, re, contextLines, maxMatches)
+		if matches == 0 {
+			return fmt.Sprintf("No line of log %d matches %q.", args.LogId, args.Grep), nil
+		}
+		return args.limit(out, maxOutput)
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "get_build_timeline",
+		Description: "Get the stage, phase, job and task tree of a build with the state, result, timing, error and warning counts, issues and log ID of each record. Use it to find the failing task before fetching logs",
+		Annotations: readOnly("Get build timeline"),
+	}, func(ctx context.Context, args buildArgs) (string, error) {
+		timeline, err := client.GetBuildTimeline(ctx, args.Project, args.BuildId)
+		if err != nil {
+			return "", err
+		}
+		return renderTimeline(timeline), nil
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "list_releases",
+		Description: "List recent releases, optionally filtered by definition, environment, status, creator, time range, source branch, artifact version and name",
+		Annotations: readOnly("List releases"),
+	}, func(ctx context.Context, args listReleasesArgs) (*releaseList, error) {
+		token, err := decodeCursor(args.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := client.GetReleases(ctx, args.Project, azuredevops.ReleaseQuery{
+			Top:                     topOrDefault(args.Top),
+			ContinuationToken:       token,
+			DefinitionId:            args.DefinitionId,
+			DefinitionEnvironmentId: args.DefinitionEnvironmentId,
+			StatusFilter:            args.StatusFilter,
+			EnvironmentStatusFilter: args.EnvironmentStatusFilter,
+			CreatedBy:               args.CreatedBy,
+			MinCreatedTime:          args.MinCreatedTime,
+			MaxCreatedTime:          args.MaxCreatedTime,
+			SourceBranchFilter:      branchRef(args.SourceBranchFilter),
+			ArtifactTypeId:          args.ArtifactTypeId,
+			ArtifactVersionId:       args.ArtifactVersionId,
+			SearchText:              args.SearchText,
+			// Environment filters are only useful if the environments are shown
+			ExpandEnvironments: args.DefinitionEnvironmentId > 0 || len(args.EnvironmentStatusFilter) > 0,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &releaseList{Releases: page.Releases, NextCursor: encodeCursor(page.ContinuationToken)}, nil
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "get_release",
+		Description: "Get release details",
+		Annotations: readOnly("Get release"),
+	}, func(ctx context.Context, args releaseArgs) (*azuredevops.Release, error) {
+		return client.GetRelease(ctx, args.Project, args.ReleaseId)
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "get_release_logs",
+		Description: "Get release logs",
+		Annotations: readOnly("Get release logs"),
+	}, func(ctx context.Context, args releaseLogsArgs) (string, error) {
+		logs, err := client.GetReleaseLogs(withProgress(ctx), args.Project, args.ReleaseId)
+		if err != nil {
+			return "", err
+		}
+		return args.limit(logs, maxOutput)
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "summarize_failure",
+		Description: "Summarize why a build or release failed: the failed tasks and the deduplicated errors and warnings from pipeline issues and compiler or test runner output, with log IDs and line numbers. Start here before fetching whole logs",
+		Annotations: readOnly("Summarize failure"),
+	}, func(ctx context.Context, args summarizeFailureArgs) (string, error) {
+		target := azuredevops.ParsedResource{Project: args.Project}
+		switch {
+		case args.URL != "" && (args.BuildId > 0 || args.ReleaseId > 0),
+			args.BuildId > 0 && args.ReleaseId > 0:
+			return "", &mcp.ArgumentError{Field: "url", Reason: "exactly one of buildId, releaseId and url is allowed"}
+		case args.URL != "":
+			parsed, err := azuredevops.ParseURL(args.URL)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse URL: %v", err)
+			}
+			target = *parsed
+		case args.BuildId > 0:
+			target.Type, target.ID = azuredevops.ResourceBuild, args.BuildId
+		case args.ReleaseId > 0:
+			target.Type, target.ID = azuredevops.ResourceRelease, args.ReleaseId
+		default:
+			return "", &mcp.ArgumentError{Field: "buildId", Reason: "one of buildId, releaseId and url is required"}
+		}
+
+		ctx = withProgress(ctx)
+		var summary *azuredevops.FailureSummary
+		var err error
+		switch target.Type {
+		case azuredevops.ResourceBuild:
+			summary, err = client.AnalyzeBuild(ctx, target.Project, target.ID)
+		case azuredevops.ResourceRelease:
+			summary, err = client.AnalyzeRelease(ctx, target.Project, target.ID)
+		default:
+			return "", fmt.Errorf("unknown resource type")
+		}
+		if err != nil {
+			return "", err
+		}
+		return renderFailureSummary(summary), nil
+	})
+
+	addTool(server, mcp.Tool{
+		Name:        "get_logs_from_url",
+		Description: "Get logs from a build or release URL. For builds, only the logs of failed tasks are returned unless onlyFailed is false",
+		Annotations: readOnly("Get logs from URL"),
+	}, func(ctx context.Context, args urlArgs) (string, error) {
+		parsed, err := azuredevops.ParseURL(args.URL)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse URL: %v", err)
+		}
+
+		ctx = withProgress(ctx)
+		var logs string
+		switch parsed.Type {
+		case azuredevops.ResourceBuild:
+			onlyFailed := args.OnlyFailed == nil || *args.OnlyFailed
+			logs, err = buildLogs(ctx, client, parsed.Project, parsed.ID, onlyFailed)
+		case azuredevops.ResourceRelease:
+			logs, err = client.GetReleaseLogs(ctx, parsed.Project, parsed.ID)
+		default:
+			return "", fmt.Errorf("unknown resource type")
+		}
+		if err != nil {
+			return "", err
+		}
+		return args.limit(logs, maxOutput)
+	})
+}
+
+// addTool registers a tool like mcp.AddTool, turning Azure DevOps API errors
+// into short messages (see describeError).
+func addTool[In, Out any](server *mcp.Server, tool mcp.Tool, fn func(ctx context.Context, in In) (Out, error)) {
+	mcp.AddTool(server, tool, func(ctx context.Context, in In) (Out, error) {
+		out, err := fn(ctx, in)
+		return out, describeError(err)
+	})
+}
+
+// buildLogs fetches all logs of a build, or only those of its failed tasks.
+func buildLogs(ctx context.Context, client *azuredevops.Client, project string, buildId int, onlyFailed bool) (string, error) {
+	if !onlyFailed {
+		return client.GetBuildLogs(ctx, project, buildId)
+	}
+
+	logs, err := client.GetFailedBuildLogs(ctx, project, buildId)
+	if err != nil {
+		return "", err
+	}
+	if logs == "" {
+		return fmt.Sprintf("No task of build %d failed or was canceled. Set onlyFailed to false to get all logs.", buildId), nil
+	}
+	return logs, nil
+}
+
+// buildLogLineCount looks up the number of lines of a build log.
+func buildLogLineCount(ctx context.Context, client *azuredevops.Client, project string, buildId, logId int) (int, error) {
+	logList, err := client.GetBuildLogList(ctx, project, buildId)
+	if err != nil {
+		return 0, err
+	}
+	for _, l := range logList {
+		if l.Id == logId {
+			return l.LineCount, nil
+		}
+	}
+	return 0, fmt.Errorf("build %d has no log %d", buildId, logId)
+}
+
+// renderTimeline writes a timeline as an indented tree, one record per line
+// followed by its issues.
+func renderTimeline(timeline *azuredevops.Timeline) string {
+	var out strings.Builder
+	var render func(parentId string, depth int)
+	render = func(parentId string, depth int) {
+		for _, r := range timeline.Children(parentId) {
+			indent := strings.Repeat("  ", depth)
+			out.WriteString(fmt.Sprintf("%s%s %q: %s", indent, r.Type, r.Name, r.State))
+			if r.Result != "" {
+				out.WriteString("/" + r.Result)
+			}
+			if r.StartTime != nil {
+				out.WriteString(", started " + r.StartTime.UTC().Format(time.RFC3339))
+			}
+			if r.FinishTime != nil {
+				out.WriteString(", finished " + r.FinishTime.UTC().Format(time.RFC3339))
+			}
+			if d := r.Duration(); d > 0 {
+				out.WriteString(fmt.Sprintf(" (%s)", d.Round(time.Second)))
+			}
+			if r.ErrorCount > 0 || r.WarningCount > 0 {
+				out.WriteString(fmt.Sprintf(", %d errors, %d warnings", r.ErrorCount, r.WarningCount))
+			}
+			if id := r.LogId(); id > 0 {
+				out.WriteString(fmt.Sprintf(", log %d", id))
+			}
+			out.WriteString("\n")
+
+			for _, issue := range r.Issues {
+				out.WriteString(fmt.Sprintf("%s  [%s] %s\n", indent, issue.Type, issue.Message))
+			}
+			if r.Id != "" {
+				render(r.Id, depth+1)
+			}
+		}
+	}
+	render("", 0)
+
+	if out.Len() == 0 {
+		return "The build has no timeline records yet."
+	}
+	return out.String()
+}
+
+// Caps on the findings listed by summarize_failure, so that a build with
+// thousands of warnings still yields a concise summary.
+const (
+	maxSummaryErrors   = 30
+	maxSummaryWarnings = 10
+)
+
+// renderFailureSummary writes a failure summary as text: the outcome, the
+// failed tasks, then errors and warnings with where they were found.
+func renderFailureSummary(summary *azuredevops.FailureSummary) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("%s: %s", summary.Title, summary.Status))
+	if summary.Result != "" {
+		out.WriteString("/" + summary.Result)
+	}
+	out.WriteString("\n")
+
+	if len(summary.FailedTasks) == 0 {
+		out.WriteString("\nNo task failed or was canceled.\n")
+	} else {
+		out.WriteString("\nFailed tasks:\n")
+		for _, task := range summary.FailedTasks {
+			out.WriteString(fmt.Sprintf("- %s: %s", task.Name, task.Result))
+			if task.LogId > 0 {
+				out.WriteString(fmt.Sprintf(" (log %d)", task.LogId))
+			}
+			out.WriteString("\n")
+		}
+	}
+
+	for _, severity := range []struct {
+		name    string
+		heading string
+		limit   int
+	}{
+		{"error", "Errors", maxSummaryErrors},
+		{"warning", "Warnings", maxSummaryWarnings},
+	} {
+		var findings []azuredevops.Finding
+		for _, f := range summary.Findings {
+			if f.Severity == severity.name {
+				findings = append(findings, f)
+			}
+		}
+		if len(findings) == 0 {
+			continue
+		}
+
+		out.WriteString(fmt.Sprintf("\n%s:\n", severity.heading))
+		for i, f := range findings {
+			if i == severity.limit {
+				out.WriteString(fmt.Sprintf("[... %d more %ss omitted ...]\n", len(findings)-i, severity.name))
+				break
+			}
+			out.WriteString(fmt.Sprintf("- [%s] %s\n", f.Source, f.Message))
+
+			var where []string
+			if f.Task != "" {
+				where = append(where, f.Task)
+			}
+			if f.LogId > 0 {
+				where = append(where, fmt.Sprintf("log %d", f.LogId))
+			}
+			if f.Line > 0 {
+				where = append(where, fmt.Sprintf("line %d", f.Line))
+			}
+			if f.Count > 1 {
+				where = append(where, fmt.Sprintf("%d occurrences", f.Count))
+			}
+			if len(where) > 0 {
+				out.WriteString("  " + strings.Join(where, ", ") + "\n")
+			}
+		}
+	}
+
+	return out.String()
+}
+
+// readOnly annotates a tool that only reads from Azure DevOps.
+func readOnly(title string) *mcp.ToolAnnotations {
+	yes, no := true, false
+	return &mcp.ToolAnnotations{
+		Title:           title,
+		ReadOnlyHint:    &yes,
+		DestructiveHint: &no,
+		IdempotentHint:  &yes,
+		OpenWorldHint:   &yes,
+	}
+}
+
+// encodeCursor wraps an ADO continuation token into an opaque cursor.
+func encodeCursor(token string) string {
+	if token == "" {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	token, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", &mcp.ArgumentError{Field: "cursor", Reason: "is not a valid cursor"}
+	}
+	return string(token), nil
+}
+
+// branchRef expands a short branch name such as main to refs/heads/main.
+func branchRef(name string) string {
+	if name == "" || strings.HasPrefix(name, "refs/") {
+		return name
+	}
+	return "refs/heads/" + name
+}
+
+func topOrDefault(top int) int {
+	if top <= 0 {
+		return 10
+	}
+	return top
+}
+
+// withProgress forwards progress reported by the ADO client to the MCP
+// client, when the request carries a progress token.
+func withProgress(ctx context.Context) context.Context {
+	return azuredevops.WithProgress(ctx, func(done, total int, message string) {
+		mcp.ReportProgress(ctx, float64(done), float64(total), message)
+	})
+}